@@ -14,7 +14,7 @@ import (
 	"github.com/marianogappa/truco/truco"
 )
 
-func Bot(playerID int, address string, bot truco.Bot) {
+func Bot(playerID int, address string, gameID string, bot truco.Bot) {
 	// Open the WebSocket connection, and send a hello message.
 	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%v/ws", address), nil)
 	if err != nil {
@@ -24,13 +24,44 @@ func Bot(playerID int, address string, bot truco.Bot) {
 
 	// Hello message is meant to tell the server who we are, and request game state.
 	// Game could be in progress (this could be a reconnection).
-	if err := server.WsSend(conn, server.NewMessageHello(playerID)); err != nil {
+	if err := server.WsSend(conn, server.NewMessageHello(gameID, playerID)); err != nil {
 		log.Fatal(err)
 	}
 
 	// On each iteration
 	for {
-		clientGameState, err := server.WsReadMessage[truco.ClientGameState, server.MessageHeresGameState](conn, server.MessageTypeHeresGameState)
+		messageType, message, err := server.WsReadAnyMessage(conn)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// If the server rejected our last action, the bot probably acted on a stale game state.
+		// Ask for the current one and carry on.
+		if messageType == server.MessageTypeError {
+			msgErr, err := server.WsDeserializeMessage[server.MessageError, server.MessageError](message, messageType)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Println("Server rejected action:", msgErr)
+			if err := server.WsSend(conn, server.NewMessageGimmeGameState()); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+
+		// Bots are good sports: they always let their opponent take back an action.
+		if messageType == server.MessageTypeTakeBackRequest {
+			if err := server.WsSend(conn, server.NewMessageTakeBackResponse(playerID, true)); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+
+		if messageType != server.MessageTypeHeresGameState {
+			continue
+		}
+
+		clientGameState, err := server.WsDeserializeMessage[truco.ClientGameState, server.MessageHeresGameState](message, server.MessageTypeHeresGameState)
 		if err != nil {
 			log.Fatal(err)
 		}
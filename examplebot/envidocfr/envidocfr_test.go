@@ -0,0 +1,83 @@
+package envidocfr
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandWithEveryEnvidoScore(t *testing.T) {
+	for envidoScore := 0; envidoScore <= maxEnvidoScore; envidoScore++ {
+		hand, err := handWithEnvidoScore(envidoScore)
+		if envidoScore > 7 && envidoScore < 20 {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, envidoScore, hand.EnvidoScore())
+		require.False(t, hand.HasFlor())
+	}
+}
+
+// TestInfoSetsOfTheTreeCanBePlayed checks that every decision of the envido game can be played in a
+// real game, with the same actions, and that the real game has the same information set.
+func TestInfoSetsOfTheTreeCanBePlayed(t *testing.T) {
+	rules := truco.New().Rules
+	_, nodes := newTree(rules)
+	for _, n := range nodes {
+		if n.isTerminal() {
+			continue
+		}
+		is := n.infoSet(Situation{ManoScore: 7, PieScore: 12}, n.player, 27)
+		gs, err := is.ClientGameState(rules)
+		require.NoError(t, err)
+		require.Equal(t, 7, gs.Players[MANO].Score)
+		require.Equal(t, 12, gs.Players[PIE].Score)
+
+		got, ok := InfoSetOf(gs)
+		require.True(t, ok)
+		require.Equal(t, is, got)
+
+		possibleActionNames := []string{}
+		for _, a := range gs.PossibleActions {
+			action, err := truco.DeserializeAction(a)
+			require.NoError(t, err)
+			possibleActionNames = append(possibleActionNames, action.GetName())
+		}
+		for _, action := range n.actions {
+			if action != PASS {
+				require.Contains(t, possibleActionNames, action, "history %v", n.history)
+			}
+		}
+	}
+}
+
+func TestTrainingReducesExploitability(t *testing.T) {
+	var (
+		rules     = truco.New().Rules
+		situation = Situation{ManoScore: 2, PieScore: 2}
+		strategy  = Train(rules, 2000, 1)
+		uniform   = func(_ InfoSet, actions []string) map[string]float64 {
+			probabilities := map[string]float64{}
+			for _, action := range actions {
+				probabilities[action] = 1 / float64(len(actions))
+			}
+			return probabilities
+		}
+	)
+	trained := Exploitability(rules, strategy.Probabilities, situation)
+	require.Greater(t, trained, 0.0)
+	require.Less(t, trained, Exploitability(rules, uniform, situation)/2)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	strategy := Train(truco.New().Rules, 100, 1)
+	path := filepath.Join(t.TempDir(), "envido.json")
+	require.NoError(t, strategy.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, strategy, loaded)
+}
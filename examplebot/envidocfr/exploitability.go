@@ -0,0 +1,136 @@
+package envidocfr
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// distributionDeals is the number of deals used to estimate how likely each pair of envido scores
+// is, which is enough for the exploitability to be precise to a hundredth of a point.
+const distributionDeals = 200000
+
+var (
+	distributionsMu sync.Mutex
+	distributions   = map[bool]*[maxEnvidoScore + 1][maxEnvidoScore + 1]float64{}
+)
+
+// envidoScoresDistribution returns the probability of each pair of envido scores of the mano and
+// the pie. It's estimated from random deals, always the same ones, so that it can be compared.
+func envidoScoresDistribution(rules truco.Rules) *[maxEnvidoScore + 1][maxEnvidoScore + 1]float64 {
+	distributionsMu.Lock()
+	defer distributionsMu.Unlock()
+	if distribution, ok := distributions[rules.IsFlorEnabled]; ok {
+		return distribution
+	}
+	var (
+		distribution [maxEnvidoScore + 1][maxEnvidoScore + 1]float64
+		r            = rand.New(rand.NewSource(0))
+	)
+	for i := 0; i < distributionDeals; i++ {
+		envidoScores := dealEnvidoScores(rules, r)
+		distribution[envidoScores[MANO]][envidoScores[PIE]] += 1.0 / distributionDeals
+	}
+	distributions[rules.IsFlorEnabled] = &distribution
+	return &distribution
+}
+
+// Exploitability returns how many points per envido a best response to the given policy wins on
+// average over both positions, in the given score situation, i.e. how much an opponent that knows
+// the policy can take advantage of it. A Nash equilibrium has an exploitability of 0.
+func Exploitability(rules truco.Rules, policy Policy, situation Situation) float64 {
+	var (
+		root, _      = newTree(rules)
+		distribution = envidoScoresDistribution(rules)
+		total        float64
+	)
+	// Envido scores from 8 to 19 can't be dealt, so they are skipped.
+	isDealt := [maxEnvidoScore + 1]bool{}
+	for manoEnvidoScore := range distribution {
+		for pieEnvidoScore, probability := range distribution[manoEnvidoScore] {
+			if probability > 0 {
+				isDealt[manoEnvidoScore], isDealt[pieEnvidoScore] = true, true
+			}
+		}
+	}
+	for player := MANO; player <= PIE; player++ {
+		for envidoScore := 0; envidoScore <= maxEnvidoScore; envidoScore++ {
+			if !isDealt[envidoScore] {
+				continue
+			}
+			opponentReach := make([]float64, maxEnvidoScore+1)
+			for opponentEnvidoScore := range opponentReach {
+				if isDealt[opponentEnvidoScore] {
+					opponentReach[opponentEnvidoScore] = 1
+				}
+			}
+			br := bestResponse{rules: rules, policy: policy, situation: situation, distribution: distribution, player: player}
+			total += br.value(root, envidoScore, opponentReach)
+		}
+	}
+	return total / 2
+}
+
+// bestResponse calculates the value of the best response of a player to a policy.
+type bestResponse struct {
+	rules        truco.Rules
+	policy       Policy
+	situation    Situation
+	distribution *[maxEnvidoScore + 1][maxEnvidoScore + 1]float64
+	player       int
+}
+
+// value returns the points the player is expected to win in the subtree with the given envido
+// score, weighted by how likely it is, when the opponent plays to the node with the given
+// probability for each of their envido scores.
+func (br bestResponse) value(n *node, envidoScore int, opponentReach []float64) float64 {
+	if n.isTerminal() {
+		value := 0.0
+		for opponentEnvidoScore, reach := range opponentReach {
+			envidoScores := [2]int{envidoScore, opponentEnvidoScore}
+			if br.player == PIE {
+				envidoScores = [2]int{opponentEnvidoScore, envidoScore}
+			}
+			probability := br.distribution[envidoScores[MANO]][envidoScores[PIE]] * reach
+			if probability == 0 {
+				continue
+			}
+			points := n.manoPoints(br.rules, br.situation, envidoScores)
+			if br.player == PIE {
+				points = -points
+			}
+			value += probability * points
+		}
+		return value
+	}
+
+	if n.player == br.player {
+		best := 0.0
+		for i, child := range n.children {
+			if value := br.value(child, envidoScore, opponentReach); i == 0 || value > best {
+				best = value
+			}
+		}
+		return best
+	}
+
+	childReaches := make([][]float64, len(n.children))
+	for i := range childReaches {
+		childReaches[i] = make([]float64, len(opponentReach))
+	}
+	for opponentEnvidoScore, reach := range opponentReach {
+		if reach == 0 {
+			continue
+		}
+		probabilities := br.policy(n.infoSet(br.situation, n.player, opponentEnvidoScore), n.actions)
+		for i, action := range n.actions {
+			childReaches[i][opponentEnvidoScore] = reach * probabilities[action]
+		}
+	}
+	value := 0.0
+	for i, child := range n.children {
+		value += br.value(child, envidoScore, childReaches[i])
+	}
+	return value
+}
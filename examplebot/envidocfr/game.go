@@ -0,0 +1,217 @@
+// Package envidocfr solves envido bidding with counterfactual regret minimisation (CFR).
+//
+// Envido is modelled as a game of its own, between two players who only know their own envido
+// score: the mano may say envido (or real envido, or falta envido) or pass, i.e. play a card; then,
+// if the mano passed, the pie may say it or pass too. Each bid can be accepted, rejected or raised,
+// as in the real game (see truco.EnvidoSequence), and an accepted envido is won by the highest
+// score, the mano winning ties. Points are worth the same regardless of the score, except that
+// nobody can score more points than they need to win, and falta envido depends on the score.
+//
+// The rest of the round isn't modelled (e.g. a bid doesn't tell anything about the truco), nor is
+// flor: with flor enabled, deals in which a player has flor are skipped, since they have no envido.
+//
+// Train solves the game with CFR for every score situation, and the resulting Strategy can be used
+// by bots, or as a benchmark to measure how exploitable other envido strategies are (see
+// Exploitability).
+package envidocfr
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// PASS is the action of not saying envido when it's possible, e.g. playing a card instead.
+const PASS = "pass"
+
+// Positions of the players in the envido game.
+const (
+	MANO = 0
+	PIE  = 1
+)
+
+// ScoreBucketSize is the size of the score ranges that are solved as a single score situation, e.g.
+// scores 0 to 4 are solved as 2 points.
+const ScoreBucketSize = 5
+
+// maxEnvidoScore is the highest envido score, i.e. 7 and 6 of the same suit.
+const maxEnvidoScore = 33
+
+var bids = []string{truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO}
+
+// Situation is the score of each player when the envido is played.
+type Situation struct {
+	ManoScore int `json:"manoScore"`
+	PieScore  int `json:"pieScore"`
+}
+
+// Situations returns the score situations that are solved for the given rules: one for each pair
+// of score buckets, at the middle of the bucket.
+func Situations(rules truco.Rules) []Situation {
+	result := []Situation{}
+	for manoBucket := 0; manoBucket*ScoreBucketSize < rules.MaxPoints; manoBucket++ {
+		for pieBucket := 0; pieBucket*ScoreBucketSize < rules.MaxPoints; pieBucket++ {
+			result = append(result, Situation{ManoScore: bucketScore(rules, manoBucket), PieScore: bucketScore(rules, pieBucket)})
+		}
+	}
+	return result
+}
+
+// bucketScore is the score the given bucket is solved with.
+func bucketScore(rules truco.Rules, bucket int) int {
+	return min(bucket*ScoreBucketSize+ScoreBucketSize/2, rules.MaxPoints-1)
+}
+
+// InfoSet is what a player knows when deciding what to bid: an information set of the envido game.
+type InfoSet struct {
+	// YourScore and TheirScore are the players' scores.
+	YourScore  int `json:"yourScore"`
+	TheirScore int `json:"theirScore"`
+
+	IsMano      bool `json:"isMano"`
+	EnvidoScore int  `json:"envidoScore"`
+
+	// History has the actions so far: PASS if the mano passed, and the envido steps said, e.g.
+	// [PASS, SAY_ENVIDO] when the mano passed and the pie said envido.
+	History []string `json:"history"`
+}
+
+// Key identifies the information set in a Strategy. Scores are grouped in buckets of
+// ScoreBucketSize points.
+func (is InfoSet) Key() string {
+	position := "pie"
+	if is.IsMano {
+		position = "mano"
+	}
+	return fmt.Sprintf("%v-%v %v %v %v", is.YourScore/ScoreBucketSize, is.TheirScore/ScoreBucketSize, position, is.EnvidoScore, strings.Join(is.History, ","))
+}
+
+// node is a node of the envido game tree. Decision nodes have the player who acts and their
+// actions; terminal nodes have none.
+type node struct {
+	id       int
+	history  []string
+	player   int
+	actions  []string
+	children []*node
+
+	// sequence has the envido steps so far, and bidder is who made the last bid, so that the
+	// winner and the points can be calculated on terminal nodes.
+	sequence truco.EnvidoSequence
+	bidder   int
+}
+
+func (n *node) isTerminal() bool {
+	return len(n.actions) == 0
+}
+
+// infoSet returns the node's information set for the given player and envido score.
+func (n *node) infoSet(situation Situation, player int, envidoScore int) InfoSet {
+	is := InfoSet{YourScore: situation.ManoScore, TheirScore: situation.PieScore, IsMano: player == MANO, EnvidoScore: envidoScore, History: n.history}
+	if player == PIE {
+		is.YourScore, is.TheirScore = situation.PieScore, situation.ManoScore
+	}
+	return is
+}
+
+// newTree returns the root of the envido game tree for the given rules, and all its nodes indexed
+// by their id.
+func newTree(rules truco.Rules) (*node, []*node) {
+	nodes := []*node{}
+	var build func(history []string, sequence truco.EnvidoSequence, bidder int) *node
+	build = func(history []string, sequence truco.EnvidoSequence, bidder int) *node {
+		n := &node{id: len(nodes), history: history, sequence: sequence, bidder: bidder}
+		nodes = append(nodes, n)
+
+		var last string
+		if len(history) > 0 {
+			last = history[len(history)-1]
+		}
+		switch {
+		case last == truco.SAY_ENVIDO_QUIERO || last == truco.SAY_ENVIDO_NO_QUIERO || (len(history) == 2 && last == PASS):
+			return n
+		case sequence.IsEmpty():
+			n.player = len(history)
+			n.actions = append(n.actions, PASS)
+		default:
+			n.player = 1 - bidder
+			n.actions = append(n.actions, truco.SAY_ENVIDO_QUIERO, truco.SAY_ENVIDO_NO_QUIERO)
+		}
+		for _, bid := range bids {
+			if sequence.CanAddStep(bid) {
+				n.actions = append(n.actions, bid)
+			}
+		}
+
+		for _, action := range n.actions {
+			childHistory := append(append([]string{}, history...), action)
+			childSequence, childBidder := sequence, bidder
+			if action != PASS {
+				childSequence, _ = sequence.WithStep(action)
+				if action != truco.SAY_ENVIDO_QUIERO && action != truco.SAY_ENVIDO_NO_QUIERO {
+					childBidder = n.player
+				}
+			}
+			n.children = append(n.children, build(childHistory, childSequence, childBidder))
+		}
+		return n
+	}
+	root := build([]string{}, truco.EnvidoSequence{IsRealEnvidoRepeatable: rules.IsRealEnvidoRepeatable}, -1)
+	return root, nodes
+}
+
+// manoPoints returns the points the mano wins (or loses, if negative) on a terminal node, given
+// the score situation and the players' envido scores.
+func (n *node) manoPoints(rules truco.Rules, situation Situation, envidoScores [2]int) float64 {
+	if n.sequence.IsEmpty() {
+		return 0
+	}
+	winner := n.bidder
+	if n.sequence.WasAccepted() {
+		winner = MANO
+		if envidoScores[PIE] > envidoScores[MANO] {
+			winner = PIE
+		}
+	}
+	scores := [2]int{situation.ManoScore, situation.PieScore}
+	cost, err := n.sequence.Cost(rules, scores[winner], scores[1-winner], true)
+	if err != nil {
+		// Unreachable: the tree only has valid sequences.
+		panic(err)
+	}
+	points := float64(min(cost, rules.MaxPoints-scores[winner]))
+	if winner == PIE {
+		return -points
+	}
+	return points
+}
+
+// dealEnvidoScores deals a random hand to each player, and returns their envido scores. With flor
+// enabled, hands with flor are dealt again.
+func dealEnvidoScores(rules truco.Rules, r *rand.Rand) [2]int {
+	cards := spanishCards()
+	for {
+		// Only the first 6 cards need to be shuffled.
+		for i := 0; i < 6; i++ {
+			j := i + r.Intn(len(cards)-i)
+			cards[i], cards[j] = cards[j], cards[i]
+		}
+		hands := [2]truco.Hand{{Unrevealed: cards[0:3]}, {Unrevealed: cards[3:6]}}
+		if rules.IsFlorEnabled && (hands[MANO].HasFlor() || hands[PIE].HasFlor()) {
+			continue
+		}
+		return [2]int{hands[MANO].EnvidoScore(), hands[PIE].EnvidoScore()}
+	}
+}
+
+func spanishCards() []truco.Card {
+	cards := []truco.Card{}
+	for _, suit := range []string{truco.ORO, truco.COPA, truco.ESPADA, truco.BASTO} {
+		for _, number := range []int{1, 2, 3, 4, 5, 6, 7, 10, 11, 12} {
+			cards = append(cards, truco.Card{Suit: suit, Number: number})
+		}
+	}
+	return cards
+}
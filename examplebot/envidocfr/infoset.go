@@ -0,0 +1,139 @@
+package envidocfr
+
+import (
+	"fmt"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// InfoSetOf returns the information set of the envido game that the player is in, or false if the
+// envido game doesn't model the player's situation, e.g. in team games, with flor, or once the
+// envido was accepted or rejected.
+func InfoSetOf(gs truco.ClientGameState) (InfoSet, bool) {
+	if len(gs.Players) != 2 || len(gs.History) == 0 {
+		return InfoSet{}, false
+	}
+	hand := truco.Hand{Revealed: gs.YourRevealedCards, Unrevealed: gs.YourUnrevealedCards}
+	if gs.Rules.IsFlorEnabled && hand.HasFlor() {
+		return InfoSet{}, false
+	}
+	is := InfoSet{
+		YourScore:   gs.YourScore,
+		TheirScore:  gs.TheirScore,
+		IsMano:      gs.RoundTurnPlayerID == gs.YouPlayerID,
+		EnvidoScore: hand.EnvidoScore(),
+		History:     []string{},
+	}
+	for _, actionLog := range gs.History[len(gs.History)-1].ActionsLog {
+		action, err := truco.DeserializeAction(actionLog.Action)
+		if err != nil {
+			return InfoSet{}, false
+		}
+		switch name := action.GetName(); name {
+		case truco.SAY_ENVIDO_QUIERO, truco.SAY_ENVIDO_NO_QUIERO, truco.SAY_FLOR:
+			// The envido was played, or the flor replaced it.
+			return InfoSet{}, false
+		case truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO:
+			// The pie can only start the envido if the mano passed.
+			if len(is.History) == 0 && action.GetPlayerID() != gs.RoundTurnPlayerID {
+				is.History = append(is.History, PASS)
+			}
+			is.History = append(is.History, name)
+		}
+	}
+	if len(is.History) == 0 && !is.IsMano {
+		is.History = append(is.History, PASS)
+	}
+	return is, true
+}
+
+// ClientGameState returns a ClientGameState of a two-player game with the given rules, in which
+// the player is in the information set, e.g. to ask a bot what it would bid.
+//
+// The player's hand is one with their envido score, and the other cards are chosen to interfere
+// as little as possible: their opponent's hand is low, and the mano passes by revealing their
+// lowest card.
+func (is InfoSet) ClientGameState(rules truco.Rules) (truco.ClientGameState, error) {
+	yourHand, err := handWithEnvidoScore(is.EnvidoScore)
+	if err != nil {
+		return truco.ClientGameState{}, err
+	}
+	theirHand := truco.Hand{Unrevealed: []truco.Card{{Suit: truco.BASTO, Number: 4}, {Suit: truco.ESPADA, Number: 5}, {Suit: truco.COPA, Number: 6}}}
+
+	youPlayerID, themPlayerID := PIE, MANO
+	if is.IsMano {
+		youPlayerID, themPlayerID = MANO, PIE
+	}
+	hands := map[int]truco.Hand{youPlayerID: yourHand, themPlayerID: theirHand}
+	gameState := truco.New(truco.WithRules(rules), truco.WithDealer(func(_ int, playerID int) truco.Hand { return hands[playerID] }))
+	for _, step := range is.History {
+		var action truco.Action
+		switch step {
+		case PASS:
+			action = truco.NewActionRevealCard(lowestCard(hands[MANO]), MANO)
+		case truco.SAY_ENVIDO:
+			action = truco.NewActionSayEnvido(gameState.TurnPlayerID)
+		case truco.SAY_REAL_ENVIDO:
+			action = truco.NewActionSayRealEnvido(gameState.TurnPlayerID)
+		case truco.SAY_FALTA_ENVIDO:
+			action = truco.NewActionSayFaltaEnvido(gameState.TurnPlayerID)
+		default:
+			return truco.ClientGameState{}, fmt.Errorf("unexpected step %v in history", step)
+		}
+		action.Enrich(*gameState)
+		if err := gameState.RunAction(action); err != nil {
+			return truco.ClientGameState{}, err
+		}
+	}
+
+	// The game starts 0 to 0, so it's determinized with the scores of the information set.
+	gs := gameState.ToClientGameState(youPlayerID)
+	gs.Players[youPlayerID].Score, gs.Players[themPlayerID].Score = is.YourScore, is.TheirScore
+	withScores, err := truco.Determinize(gs, map[int]truco.Hand{themPlayerID: theirHand})
+	if err != nil {
+		return truco.ClientGameState{}, err
+	}
+	return withScores.ToClientGameState(youPlayerID), nil
+}
+
+// handWithEnvidoScore returns a hand with the given envido score, using oro and the lowest cards of
+// the other suits.
+func handWithEnvidoScore(envidoScore int) (truco.Hand, error) {
+	var cards []truco.Card
+	switch {
+	case envidoScore >= 20 && envidoScore <= maxEnvidoScore:
+		// Two cards of oro that add up to the score, e.g. 7 and 6 for 33.
+		high := min(envidoScore-20, 7)
+		low := envidoScore - 20 - high
+		cards = []truco.Card{oroCardOfValue(high, 10), oroCardOfValue(low, 11), {Suit: truco.COPA, Number: 4}}
+	case envidoScore >= 0 && envidoScore <= 7:
+		// No two cards of the same suit, so the score is the highest card.
+		cards = []truco.Card{oroCardOfValue(envidoScore, 10), {Suit: truco.COPA, Number: 11}, {Suit: truco.ESPADA, Number: 12}}
+	default:
+		return truco.Hand{}, fmt.Errorf("no hand has an envido score of %v", envidoScore)
+	}
+	hand := truco.Hand{Unrevealed: cards}
+	if hand.EnvidoScore() != envidoScore {
+		// Unreachable unless the envido score calculation changes.
+		return truco.Hand{}, fmt.Errorf("couldn't make a hand with an envido score of %v", envidoScore)
+	}
+	return hand, nil
+}
+
+// oroCardOfValue returns the card of oro worth the given envido value, using the given figure for 0.
+func oroCardOfValue(value int, figure int) truco.Card {
+	if value == 0 {
+		return truco.Card{Suit: truco.ORO, Number: figure}
+	}
+	return truco.Card{Suit: truco.ORO, Number: value}
+}
+
+func lowestCard(hand truco.Hand) truco.Card {
+	lowest := hand.Unrevealed[0]
+	for _, card := range hand.Unrevealed[1:] {
+		if card.CompareTrucoScore(lowest) < 0 {
+			lowest = card
+		}
+	}
+	return lowest
+}
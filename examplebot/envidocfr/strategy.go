@@ -0,0 +1,62 @@
+package envidocfr
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// Strategy is a table with the probability of each action on every information set of the envido
+// game, as trained by Train.
+type Strategy struct {
+	// Rules are the rules the strategy was trained for.
+	Rules truco.Rules `json:"rules"`
+
+	// Iterations is the number of CFR iterations run for each score situation.
+	Iterations int `json:"iterations"`
+
+	// InfoSets maps the key of each information set (see InfoSet.Key) to the probability of each
+	// of its actions.
+	InfoSets map[string]map[string]float64 `json:"infoSets"`
+}
+
+// Policy returns the probability of each of the given actions on an information set. It's how
+// Exploitability reads envido strategies.
+type Policy func(is InfoSet, actions []string) map[string]float64
+
+// Probabilities returns the probability of each of the given actions on the information set, or
+// the same probability for all of them if it isn't in the table (e.g. it was never reached while
+// training). It's the strategy's Policy.
+func (s *Strategy) Probabilities(is InfoSet, actions []string) map[string]float64 {
+	if probabilities, ok := s.InfoSets[is.Key()]; ok {
+		return probabilities
+	}
+	probabilities := map[string]float64{}
+	for _, action := range actions {
+		probabilities[action] = 1 / float64(len(actions))
+	}
+	return probabilities
+}
+
+// Save writes the strategy to a JSON file.
+func (s *Strategy) Save(path string) error {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0o644)
+}
+
+// Load reads a strategy from a JSON file written by Save.
+func Load(path string) (*Strategy, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Strategy
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,124 @@
+package envidocfr
+
+import (
+	"math/rand"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// Train solves the envido game with chance-sampling CFR for every score situation of a game with
+// the given rules, running the given number of iterations for each, and returns the average
+// strategy, which approaches a Nash equilibrium as iterations grow.
+//
+// Each iteration deals a random hand to each player, so the strategy isn't reliable for envido
+// scores that are rarely dealt unless there are many iterations (e.g. a million).
+func Train(rules truco.Rules, iterations int, seed int64) *Strategy {
+	strategy := &Strategy{Rules: rules, Iterations: iterations, InfoSets: map[string]map[string]float64{}}
+	root, nodes := newTree(rules)
+	r := rand.New(rand.NewSource(seed))
+	for _, situation := range Situations(rules) {
+		t := newTrainer(rules, situation, nodes)
+		for i := 0; i < iterations; i++ {
+			t.cfr(root, dealEnvidoScores(rules, r), [2]float64{1, 1})
+		}
+		t.addAverageStrategy(strategy)
+	}
+	return strategy
+}
+
+// trainer has the regrets and the accumulated strategy of each information set of a situation,
+// indexed by node id and envido score.
+type trainer struct {
+	rules       truco.Rules
+	situation   Situation
+	nodes       []*node
+	regrets     [][][]float64
+	strategySum [][][]float64
+}
+
+func newTrainer(rules truco.Rules, situation Situation, nodes []*node) *trainer {
+	t := &trainer{rules: rules, situation: situation, nodes: nodes}
+	for _, n := range nodes {
+		regrets := make([][]float64, maxEnvidoScore+1)
+		strategySum := make([][]float64, maxEnvidoScore+1)
+		for envidoScore := range regrets {
+			regrets[envidoScore] = make([]float64, len(n.actions))
+			strategySum[envidoScore] = make([]float64, len(n.actions))
+		}
+		t.regrets = append(t.regrets, regrets)
+		t.strategySum = append(t.strategySum, strategySum)
+	}
+	return t
+}
+
+// cfr updates the regrets of the subtree for the given envido scores, and returns the points the
+// mano is expected to win in it. reach has the probability of each player playing to the node.
+func (t *trainer) cfr(n *node, envidoScores [2]int, reach [2]float64) float64 {
+	if n.isTerminal() {
+		return n.manoPoints(t.rules, t.situation, envidoScores)
+	}
+	var (
+		player      = n.player
+		envidoScore = envidoScores[player]
+		strategy    = regretMatching(t.regrets[n.id][envidoScore])
+		utilities   = make([]float64, len(n.actions))
+		utility     float64
+	)
+	for i, child := range n.children {
+		childReach := reach
+		childReach[player] *= strategy[i]
+		utilities[i] = t.cfr(child, envidoScores, childReach)
+		utility += strategy[i] * utilities[i]
+	}
+
+	// Utilities are the mano's, so they are negated for the pie.
+	sign := 1.0
+	if player == PIE {
+		sign = -1.0
+	}
+	for i := range n.actions {
+		t.regrets[n.id][envidoScore][i] += reach[1-player] * sign * (utilities[i] - utility)
+		t.strategySum[n.id][envidoScore][i] += reach[player] * strategy[i]
+	}
+	return utility
+}
+
+// addAverageStrategy adds the average strategy of every information set that was reached while
+// training to the given strategy.
+func (t *trainer) addAverageStrategy(strategy *Strategy) {
+	for _, n := range t.nodes {
+		for envidoScore, strategySum := range t.strategySum[n.id] {
+			total := 0.0
+			for _, s := range strategySum {
+				total += s
+			}
+			if total == 0 {
+				continue
+			}
+			probabilities := map[string]float64{}
+			for i, action := range n.actions {
+				probabilities[action] = strategySum[i] / total
+			}
+			strategy.InfoSets[n.infoSet(t.situation, n.player, envidoScore).Key()] = probabilities
+		}
+	}
+}
+
+// regretMatching returns a strategy that plays each action in proportion to its positive regret,
+// or uniformly if no action has any.
+func regretMatching(regrets []float64) []float64 {
+	strategy := make([]float64, len(regrets))
+	total := 0.0
+	for i, regret := range regrets {
+		strategy[i] = max(regret, 0)
+		total += strategy[i]
+	}
+	for i := range strategy {
+		if total > 0 {
+			strategy[i] /= total
+		} else {
+			strategy[i] = 1 / float64(len(strategy))
+		}
+	}
+	return strategy
+}
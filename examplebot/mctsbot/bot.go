@@ -0,0 +1,126 @@
+// Package mctsbot is a bot that plays with Monte Carlo Tree Search.
+//
+// It doesn't know any strategy: on every decision, it guesses the cards it can't see many times
+// (see sampleGameStates), plays the round to its end on each guess with the real truco engine, and
+// picks the action with the best average point differential. It's an information-set MCTS, i.e.
+// all the guesses share a single tree of actions, so it doesn't play as if it could see the cards.
+package mctsbot
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// DefaultIterations is the number of rounds the bot simulates on every decision, if neither
+// WithIterations nor WithTimeBudget are used.
+const DefaultIterations = 1000
+
+type Bot struct {
+	iterations int
+	timeBudget time.Duration
+	rand       *rand.Rand
+	logger     Logger
+}
+
+// WithIterations sets the number of rounds the bot simulates on every decision. More iterations
+// play better, but slower.
+func WithIterations(iterations int) func(*Bot) {
+	return func(b *Bot) {
+		b.iterations = iterations
+	}
+}
+
+// WithTimeBudget sets the time the bot thinks on every decision. If the number of iterations is
+// set too, the bot stops at whichever limit is reached first.
+func WithTimeBudget(timeBudget time.Duration) func(*Bot) {
+	return func(b *Bot) {
+		b.timeBudget = timeBudget
+	}
+}
+
+// WithSeed sets the seed of the bot's guesses, so that it always chooses the same actions on the
+// same game states (as long as it isn't limited by WithTimeBudget).
+func WithSeed(seed int64) func(*Bot) {
+	return func(b *Bot) {
+		b.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+func WithDefaultLogger(b *Bot) {
+	b.logger = log.New(os.Stderr, "", log.LstdFlags)
+}
+
+func New(opts ...func(*Bot)) *Bot {
+	b := &Bot{
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger: NoOpLogger{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.iterations <= 0 && b.timeBudget <= 0 {
+		b.iterations = DefaultIterations
+	}
+	return b
+}
+
+func (b *Bot) ChooseAction(gs truco.ClientGameState) truco.Action {
+	// Trivial cases
+	actions := _deserializeActions(gs.PossibleActions)
+	if len(actions) == 0 {
+		return nil
+	}
+	if len(actions) == 1 {
+		return actions[0]
+	}
+
+	gameStates := b.sampleGameStates(gs)
+	if len(gameStates) == 0 {
+		// Unreachable unless the other players' actions can't be explained by any hand, e.g. a
+		// client that doesn't send the possible actions as they are.
+		b.logger.Printf("Couldn't guess the other players' hands; choosing the first possible action")
+		return actions[0]
+	}
+
+	var (
+		root  = newNode(-1)
+		start = time.Now()
+		i     int
+	)
+	for ; b.iterations <= 0 || i < b.iterations; i++ {
+		// Always run at least one iteration, however small the time budget.
+		if i > 0 && b.timeBudget > 0 && time.Since(start) >= b.timeBudget {
+			break
+		}
+		b.iterate(root, gameStates[i%len(gameStates)].Clone(), gs, actions)
+	}
+
+	best := root.bestChild()
+	if best == nil {
+		// The iterations failed before trying any action, e.g. the guessed game states didn't accept them.
+		b.logger.Printf("Couldn't try any action after %v iterations; choosing the first possible action", i)
+		return actions[0]
+	}
+	for _, action := range actions {
+		if actionKey(action) == best.key {
+			b.logger.Printf("Chose %v after %v iterations on %v guesses: %.2f points on average in %v visits", action, i, len(gameStates), best.meanReward(), best.visits)
+			return action
+		}
+	}
+	// Unreachable: root children are only created for the possible actions.
+	panic("mctsbot chose an action that isn't possible; please report this bug!")
+}
+
+func _deserializeActions(as []json.RawMessage) []truco.Action {
+	_as := []truco.Action{}
+	for _, a := range as {
+		_a, _ := truco.DeserializeAction(a)
+		_as = append(_as, _a)
+	}
+	return _as
+}
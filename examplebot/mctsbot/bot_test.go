@@ -0,0 +1,93 @@
+package mctsbot
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChoosesPossibleActions plays games between the bot and random players, and checks that the
+// bot always chooses one of its possible actions.
+func TestChoosesPossibleActions(t *testing.T) {
+	configs := []struct {
+		name string
+		opts []func(*truco.GameState)
+	}{
+		{name: "two players with flor", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithFlorEnabled(true)}},
+		{name: "four players", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithPlayerCount(4)}},
+		{name: "six players with pica-pica", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithPlayerCount(6), truco.WithPicaPica(5, 10)}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			var (
+				r         = rand.New(rand.NewSource(1))
+				bot       = New(WithIterations(30), WithSeed(1))
+				gameState = truco.New(append(config.opts, truco.WithSeed(1))...)
+			)
+			for step := 0; !gameState.IsGameEnded; step++ {
+				clientGameState := gameState.ToClientGameState(gameState.TurnPlayerID)
+				var action truco.Action
+				if gameState.TeamOf(gameState.TurnPlayerID) == 0 {
+					action = bot.ChooseAction(clientGameState)
+					require.Contains(t, serializedActions(clientGameState.PossibleActions), string(truco.SerializeAction(action)), "step %v", step)
+				} else {
+					actions := clientGameState.PossibleActions
+					var err error
+					action, err = truco.DeserializeAction(actions[r.Intn(len(actions))])
+					require.NoError(t, err)
+				}
+				require.NoError(t, gameState.RunAction(action), "step %v", step)
+			}
+		})
+	}
+}
+
+func TestAcceptsEnvidoWithAGoodScore(t *testing.T) {
+	hands := map[int]truco.Hand{
+		0: {Unrevealed: []truco.Card{{Suit: truco.BASTO, Number: 4}, {Suit: truco.COPA, Number: 5}, {Suit: truco.ESPADA, Number: 12}}},
+		1: {Unrevealed: []truco.Card{{Suit: truco.ORO, Number: 7}, {Suit: truco.ORO, Number: 6}, {Suit: truco.ESPADA, Number: 1}}}, // 33
+	}
+	gameState := truco.New(truco.WithDealer(func(_ int, playerID int) truco.Hand { return hands[playerID] }))
+	require.NoError(t, gameState.RunAction(truco.NewActionSayEnvido(0)))
+
+	action := New(WithIterations(300), WithSeed(1)).ChooseAction(gameState.ToClientGameState(1))
+	require.NotEqual(t, truco.SAY_ENVIDO_NO_QUIERO, action.GetName())
+}
+
+func TestRevealsTheOnlyWinningCard(t *testing.T) {
+	hands := map[int]truco.Hand{
+		0: {Unrevealed: []truco.Card{{Suit: truco.BASTO, Number: 4}, {Suit: truco.COPA, Number: 5}, {Suit: truco.ORO, Number: 12}}},
+		1: {Unrevealed: []truco.Card{{Suit: truco.COPA, Number: 4}, {Suit: truco.ESPADA, Number: 1}, {Suit: truco.ORO, Number: 6}}},
+	}
+	gameState := truco.New(truco.WithDealer(func(_ int, playerID int) truco.Hand { return hands[playerID] }))
+	require.NoError(t, gameState.RunAction(truco.NewActionRevealCard(truco.Card{Suit: truco.ORO, Number: 12}, 0)))
+	require.NoError(t, gameState.RunAction(truco.NewActionSayTruco(1)))
+	require.NoError(t, gameState.RunAction(truco.NewActionSayTrucoQuiero(0)))
+
+	// The 1 de espada wins the first faceoff for sure, and the next ones are a coin toss.
+	action := New(WithIterations(300), WithSeed(1)).ChooseAction(gameState.ToClientGameState(1))
+	require.Equal(t, truco.REVEAL_CARD, action.GetName())
+	require.Equal(t, truco.Card{Suit: truco.ESPADA, Number: 1}, action.(*truco.ActionRevealCard).Card)
+}
+
+func TestChoosesAnActionWithATinyTimeBudget(t *testing.T) {
+	gameState := truco.New(truco.WithSeed(1))
+	clientGameState := gameState.ToClientGameState(0)
+	require.Greater(t, len(clientGameState.PossibleActions), 1)
+
+	action := New(WithTimeBudget(time.Nanosecond), WithSeed(1)).ChooseAction(clientGameState)
+	require.NotNil(t, action)
+	require.Contains(t, serializedActions(clientGameState.PossibleActions), string(truco.SerializeAction(action)))
+}
+
+func serializedActions(actions []json.RawMessage) []string {
+	result := []string{}
+	for _, action := range actions {
+		result = append(result, string(action))
+	}
+	return result
+}
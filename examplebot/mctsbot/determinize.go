@@ -0,0 +1,79 @@
+package mctsbot
+
+import (
+	"math/rand"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+const (
+	// maxGameStates is how many different guesses of the hidden cards the bot simulates on.
+	maxGameStates = 64
+
+	// maxSampleAttempts is how many guesses the bot tries at most, since guesses that contradict
+	// what the other players said (e.g. their envido score) are discarded.
+	maxSampleAttempts = 1000
+)
+
+// sampleGameStates guesses the cards that the bot can't see, and returns a game state for each
+// guess that explains everything that happened in the round (see truco.Determinize).
+func (b *Bot) sampleGameStates(gs truco.ClientGameState) []*truco.GameState {
+	count := maxGameStates
+	if b.iterations > 0 {
+		// There's no point in guessing more than what will be simulated.
+		count = min(count, b.iterations)
+	}
+	gameStates := []*truco.GameState{}
+	for attempt := 0; attempt < maxSampleAttempts && len(gameStates) < count; attempt++ {
+		gameState, err := truco.Determinize(gs, sampleHands(gs, b.rand), truco.WithSeed(b.rand.Int63()))
+		if err != nil {
+			continue
+		}
+		gameStates = append(gameStates, gameState)
+	}
+	return gameStates
+}
+
+// sampleHands deals the cards that the bot hasn't seen to the other players, on top of the ones
+// they revealed.
+func sampleHands(gs truco.ClientGameState, r *rand.Rand) map[int]truco.Hand {
+	seen := map[truco.Card]bool{}
+	for _, card := range gs.YourUnrevealedCards {
+		seen[card] = true
+	}
+	for _, player := range gs.Players {
+		for _, card := range player.RevealedCards {
+			seen[card] = true
+		}
+	}
+	unseen := []truco.Card{}
+	for _, card := range spanishCards() {
+		if !seen[card] {
+			unseen = append(unseen, card)
+		}
+	}
+	r.Shuffle(len(unseen), func(i, j int) { unseen[i], unseen[j] = unseen[j], unseen[i] })
+
+	hands := map[int]truco.Hand{}
+	for _, player := range gs.Players {
+		if player.PlayerID == gs.YouPlayerID {
+			continue
+		}
+		cards := append([]truco.Card{}, player.RevealedCards...)
+		for len(cards) < 3 {
+			cards, unseen = append(cards, unseen[0]), unseen[1:]
+		}
+		hands[player.PlayerID] = truco.Hand{Unrevealed: cards}
+	}
+	return hands
+}
+
+func spanishCards() []truco.Card {
+	cards := []truco.Card{}
+	for _, suit := range []string{truco.ORO, truco.COPA, truco.ESPADA, truco.BASTO} {
+		for _, number := range []int{1, 2, 3, 4, 5, 6, 7, 10, 11, 12} {
+			cards = append(cards, truco.Card{Suit: suit, Number: number})
+		}
+	}
+	return cards
+}
@@ -0,0 +1,159 @@
+package mctsbot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// explorationConstant weighs how much the search tries actions that haven't been simulated much,
+// against the ones that scored best so far. Rewards are in points, so it's in points too.
+const explorationConstant = 2.0
+
+// node is a node of the search tree. Its children are the actions that were tried after it, and
+// its reward is from the point of view of the team of the player who ran its action.
+type node struct {
+	key         string
+	teamID      int
+	children    map[string]*node
+	visits      int
+	totalReward float64
+
+	// availability is how many times the node's action was possible when its parent was visited,
+	// since the possible actions depend on the guessed cards.
+	availability int
+}
+
+func newNode(teamID int) *node {
+	return &node{teamID: teamID, children: map[string]*node{}}
+}
+
+func (n *node) meanReward() float64 {
+	if n.visits == 0 {
+		return math.Inf(-1)
+	}
+	return n.totalReward / float64(n.visits)
+}
+
+func (n *node) ucb() float64 {
+	return n.meanReward() + explorationConstant*math.Sqrt(math.Log(float64(n.availability))/float64(n.visits))
+}
+
+// bestChild returns the child with the best average point differential.
+func (n *node) bestChild() *node {
+	var best *node
+	for _, child := range n.children {
+		if best == nil || child.meanReward() > best.meanReward() || (child.meanReward() == best.meanReward() && child.key < best.key) {
+			best = child
+		}
+	}
+	return best
+}
+
+// iterate runs one iteration of the search on a guessed game state: it goes down the tree
+// choosing the most promising actions, adds an action that wasn't tried, plays the rest of the
+// round at random, and updates the visited nodes with the resulting point differential.
+//
+// The root's actions are the bot's possible actions as given by the server, rather than the
+// guessed game state's, since their enriched fields may depend on the guessed cards.
+func (b *Bot) iterate(root *node, gameState *truco.GameState, gs truco.ClientGameState, rootActions []truco.Action) {
+	var (
+		roundNumber  = gameState.RoundNumber
+		scoresBefore = teamScores(gameState)
+		path         = []*node{}
+		current      = root
+		actions      = rootActions
+		isExpanded   = false
+	)
+	for !isRoundOver(gameState, roundNumber) && len(actions) > 0 {
+		untried := []truco.Action{}
+		for _, action := range actions {
+			if child, ok := current.children[actionKey(action)]; ok {
+				child.availability++
+			} else {
+				untried = append(untried, action)
+			}
+		}
+
+		var action truco.Action
+		if len(untried) > 0 {
+			action = untried[b.rand.Intn(len(untried))]
+			child := newNode(gameState.TeamOf(action.GetPlayerID()))
+			child.key = actionKey(action)
+			child.availability = 1
+			current.children[child.key] = child
+			isExpanded = true
+		} else {
+			bestUCB := math.Inf(-1)
+			for _, a := range actions {
+				if ucb := current.children[actionKey(a)].ucb(); ucb > bestUCB {
+					action, bestUCB = a, ucb
+				}
+			}
+		}
+		current = current.children[actionKey(action)]
+		path = append(path, current)
+		if err := gameState.RunAction(action); err != nil {
+			break
+		}
+		if isExpanded {
+			break
+		}
+		actions = turnPlayerActions(gameState)
+	}
+
+	b.rollout(gameState, roundNumber)
+
+	scoresAfter := teamScores(gameState)
+	for _, n := range path {
+		otherTeamID := 1 - n.teamID
+		n.visits++
+		n.totalReward += float64((scoresAfter[n.teamID] - scoresBefore[n.teamID]) - (scoresAfter[otherTeamID] - scoresBefore[otherTeamID]))
+	}
+}
+
+// rollout plays random actions until the round is over.
+func (b *Bot) rollout(gameState *truco.GameState, roundNumber int) {
+	for !isRoundOver(gameState, roundNumber) {
+		actions := turnPlayerActions(gameState)
+		if len(actions) == 0 {
+			return
+		}
+		if err := gameState.RunAction(actions[b.rand.Intn(len(actions))]); err != nil {
+			return
+		}
+	}
+}
+
+func isRoundOver(gameState *truco.GameState, roundNumber int) bool {
+	return gameState.IsGameEnded || gameState.RoundNumber != roundNumber
+}
+
+// turnPlayerActions returns the possible actions of the player whose turn it is.
+func turnPlayerActions(gameState *truco.GameState) []truco.Action {
+	actions := []truco.Action{}
+	for _, action := range gameState.CalculatePossibleActions() {
+		if action.GetPlayerID() == gameState.TurnPlayerID {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+func teamScores(gameState *truco.GameState) [2]int {
+	scores := [2]int{}
+	for playerID, player := range gameState.Players {
+		scores[gameState.TeamOf(playerID)] = player.Score
+	}
+	return scores
+}
+
+// actionKey identifies an action regardless of its enriched fields (e.g. an envido score), which
+// depend on the guessed cards.
+func actionKey(action truco.Action) string {
+	if revealCard, ok := action.(*truco.ActionRevealCard); ok {
+		return fmt.Sprintf("%v %v %v", action.GetPlayerID(), action.GetName(), revealCard.Card)
+	}
+	return fmt.Sprintf("%v %v", action.GetPlayerID(), action.GetName())
+}
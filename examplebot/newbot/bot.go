@@ -6,6 +6,7 @@ import (
 
 	"log"
 
+	"github.com/marianogappa/truco/examplebot/envidocfr"
 	"github.com/marianogappa/truco/truco"
 )
 
@@ -19,6 +20,14 @@ func WithDefaultLogger(b *Bot) {
 	b.logger = log.New(os.Stderr, "", log.LstdFlags)
 }
 
+// WithEnvidoStrategy makes the bot bid envido as the given strategy says (see envidocfr) in the
+// situations it models, rather than by its envido score thresholds.
+func WithEnvidoStrategy(strategy *envidocfr.Strategy) func(*Bot) {
+	return func(b *Bot) {
+		b.st["envidoStrategy"] = strategy
+	}
+}
+
 func New(opts ...func(*Bot)) *Bot {
 	// Rules organically form a DAG. Kahn flattens them into a linear order.
 	// If this is not possible (i.e. it's not a DAG), it blows up.
@@ -0,0 +1,51 @@
+package newbot
+
+import (
+	"slices"
+
+	"github.com/marianogappa/truco/examplebot/envidocfr"
+	"github.com/marianogappa/truco/truco"
+)
+
+// EnvidoPolicy returns how the bot bids envido as an envidocfr.Policy, e.g. to measure how
+// exploitable its thresholds are with envidocfr.Exploitability. Since the bot sometimes lies, it's
+// asked the given number of times on each information set, and any action that isn't an envido bid
+// counts as a pass.
+func EnvidoPolicy(rules truco.Rules, samples int, opts ...func(*Bot)) envidocfr.Policy {
+	cache := map[string]map[string]float64{}
+	return func(is envidocfr.InfoSet, actions []string) map[string]float64 {
+		key := is.Key()
+		if probabilities, ok := cache[key]; ok {
+			return probabilities
+		}
+		gs, err := is.ClientGameState(rules)
+		if err != nil {
+			// Unreachable: information sets of the envido game can always be played.
+			panic(err)
+		}
+		var (
+			bot    = New(opts...)
+			counts = map[string]int{}
+			total  = 0
+		)
+		for i := 0; i < samples; i++ {
+			actionName := envidocfr.PASS
+			if action := bot.ChooseAction(gs); action != nil && slices.Contains(actions, action.GetName()) {
+				actionName = action.GetName()
+			}
+			if slices.Contains(actions, actionName) {
+				counts[actionName]++
+				total++
+			}
+		}
+		probabilities := map[string]float64{}
+		for _, actionName := range actions {
+			probabilities[actionName] = 1 / float64(len(actions))
+			if total > 0 {
+				probabilities[actionName] = float64(counts[actionName]) / float64(total)
+			}
+		}
+		cache[key] = probabilities
+		return probabilities
+	}
+}
@@ -12,7 +12,7 @@ var (
 		name:         "ruleInitiateEnvido",
 		description:  "Decides whether to initiate an Envido action",
 		isApplicable: ruleInitiateEnvidoIsApplicable,
-		dependsOn:    []rule{ruleRespondToQuieroValeCuatro},
+		dependsOn:    []rule{ruleRespondToQuieroValeCuatro, rulePlayEnvidoStrategy},
 		run:          ruleInitiateEnvidoRun,
 	}
 )
@@ -21,8 +21,8 @@ func init() {
 	registerRule(ruleInitiateEnvido)
 }
 
-func ruleInitiateEnvidoIsApplicable(st state, _ truco.ClientGameState) bool {
-	return isPossibleAll(st, truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO)
+func ruleInitiateEnvidoIsApplicable(st state, gs truco.ClientGameState) bool {
+	return !envidoStrategyPassed(st, gs) && isPossibleAll(st, truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO)
 }
 
 func ruleInitiateEnvidoRun(st state, gs truco.ClientGameState) (ruleResult, error) {
@@ -0,0 +1,82 @@
+package newbot
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/marianogappa/truco/examplebot/envidocfr"
+	"github.com/marianogappa/truco/truco"
+)
+
+var (
+	rulePlayEnvidoStrategy = rule{
+		name:         "rulePlayEnvidoStrategy",
+		description:  "Bids envido as the envido strategy says, if the bot has one",
+		isApplicable: rulePlayEnvidoStrategyIsApplicable,
+		dependsOn:    []rule{ruleInitState},
+		run:          rulePlayEnvidoStrategyRun,
+	}
+)
+
+func init() {
+	registerRule(rulePlayEnvidoStrategy)
+}
+
+func rulePlayEnvidoStrategyIsApplicable(st state, gs truco.ClientGameState) bool {
+	if envidoStrategy(st) == nil || !isPossibleAny(st, truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO, truco.SAY_ENVIDO_QUIERO) {
+		return false
+	}
+	// Once it passed, it only plays again to respond to the opponent's bid.
+	if envidoStrategyPassed(st, gs) && !isPossibleAny(st, truco.SAY_ENVIDO_QUIERO) {
+		return false
+	}
+	_, ok := envidocfr.InfoSetOf(gs)
+	return ok
+}
+
+func rulePlayEnvidoStrategyRun(st state, gs truco.ClientGameState) (ruleResult, error) {
+	is, _ := envidocfr.InfoSetOf(gs)
+
+	// Passing is only possible when nobody has bid yet.
+	actions := []string{}
+	if !isPossibleAny(st, truco.SAY_ENVIDO_QUIERO) {
+		actions = append(actions, envidocfr.PASS)
+	}
+	for _, actionName := range []string{truco.SAY_ENVIDO_QUIERO, truco.SAY_ENVIDO_NO_QUIERO, truco.SAY_ENVIDO, truco.SAY_REAL_ENVIDO, truco.SAY_FALTA_ENVIDO} {
+		if isPossibleAny(st, actionName) {
+			actions = append(actions, actionName)
+		}
+	}
+
+	probabilities := envidoStrategy(st).Probabilities(is, actions)
+	total := 0.0
+	for _, actionName := range actions {
+		total += probabilities[actionName]
+	}
+	chosen := actions[len(actions)-1]
+	for i, r := 0, rand.Float64()*total; i < len(actions); i++ {
+		if r -= probabilities[actions[i]]; r < 0 {
+			chosen = actions[i]
+			break
+		}
+	}
+
+	if chosen == envidocfr.PASS {
+		roundNumber := gs.RoundNumber
+		return ruleResult{
+			action: nil,
+			stateChanges: []stateChange{{
+				fn: func(st *state) {
+					(*st)["envidoStrategyPassedRound"] = roundNumber
+				},
+				description: fmt.Sprintf("Set envidoStrategyPassedRound to %v", roundNumber),
+			}},
+			resultDescription: fmt.Sprintf("Decided not to bid envido with envido score of %v, as the envido strategy says with probability %.2f.", is.EnvidoScore, probabilities[chosen]/total),
+		}, nil
+	}
+	return ruleResult{
+		action:            getAction(st, chosen),
+		stateChanges:      []stateChange{},
+		resultDescription: fmt.Sprintf("Decided to %v with envido score of %v, as the envido strategy says with probability %.2f.", chosen, is.EnvidoScore, probabilities[chosen]/total),
+	}, nil
+}
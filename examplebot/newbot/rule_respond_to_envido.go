@@ -12,7 +12,7 @@ var (
 		name:         "ruleRespondToEnvido",
 		description:  "Responds to an Envido action",
 		isApplicable: ruleRespondToEnvidoIsApplicable,
-		dependsOn:    []rule{ruleInitState},
+		dependsOn:    []rule{rulePlayEnvidoStrategy},
 		run:          ruleRespondToEnvidoRun,
 	}
 )
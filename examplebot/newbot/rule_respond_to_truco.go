@@ -9,7 +9,7 @@ var (
 		name:         "ruleRespondToTruco",
 		description:  "Responds to a Truco action",
 		isApplicable: ruleRespondToTrucoIsApplicable,
-		dependsOn:    []rule{ruleInitState},
+		dependsOn:    []rule{rulePlayEnvidoStrategy},
 		run:          ruleRespondToTrucoRun,
 	}
 )
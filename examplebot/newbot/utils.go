@@ -3,6 +3,7 @@ package newbot
 import (
 	"encoding/json"
 
+	"github.com/marianogappa/truco/examplebot/envidocfr"
 	"github.com/marianogappa/truco/truco"
 )
 
@@ -24,14 +25,14 @@ func isPossibleAll(st state, actionNames ...string) bool {
 	return true
 }
 
-// func isPossibleAny(st state, actionNames ...string) bool {
-// 	for _, actionName := range actionNames {
-// 		if _, ok := st["possibleActionNameSet"].(map[string]struct{})[actionName]; ok {
-// 			return true
-// 		}
-// 	}
-// 	return false
-// }
+func isPossibleAny(st state, actionNames ...string) bool {
+	for _, actionName := range actionNames {
+		if _, ok := st["possibleActionNameSet"].(map[string]struct{})[actionName]; ok {
+			return true
+		}
+	}
+	return false
+}
 
 func getAction(st state, actionName string) truco.Action {
 	return st["possibleActions"].(map[string]truco.Action)[actionName]
@@ -52,3 +53,15 @@ func envidoScore(st state) int {
 func pointsToLose(st state) int {
 	return st["pointsToLose"].(int)
 }
+
+func envidoStrategy(st state) *envidocfr.Strategy {
+	strategy, _ := st["envidoStrategy"].(*envidocfr.Strategy)
+	return strategy
+}
+
+// envidoStrategyPassed is true if the envido strategy already decided not to bid envido this
+// round, so that the bot doesn't ask it again, nor bid by its thresholds.
+func envidoStrategyPassed(st state, gs truco.ClientGameState) bool {
+	roundNumber, ok := st["envidoStrategyPassedRound"].(int)
+	return ok && roundNumber == gs.RoundNumber
+}
@@ -0,0 +1,84 @@
+// Package randombot is a bot that chooses any of its possible actions at random. It's a baseline
+// for other bots to beat, e.g. with truco simulate, and a way to explore the game's rules, e.g.
+// with truco fuzz.
+package randombot
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// RareActionWeights makes the bot choose the actions that rarely come up in real games more often,
+// so that the rules around them are played more, e.g. to look for bugs in the engine.
+var RareActionWeights = map[string]float64{
+	truco.SAY_CONTRAFLOR_AL_RESTO: 10,
+	truco.SAY_CONTRAFLOR:          5,
+	truco.SAY_QUIERO_VALE_CUATRO:  10,
+	truco.SAY_QUIERO_RETRUCO:      5,
+	truco.SAY_FALTA_ENVIDO:        5,
+	truco.SAY_SON_MEJORES:         10,
+	truco.SAY_FLOR_SON_MEJORES:    10,
+}
+
+type Bot struct {
+	rand    *rand.Rand
+	weights map[string]float64
+}
+
+// WithSeed sets the seed of the bot's choices, so that it always chooses the same actions in the
+// same game.
+func WithSeed(seed int64) func(*Bot) {
+	return func(b *Bot) {
+		b.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithWeights makes the bot choose each action in proportion to the weight of its name, rather than
+// uniformly. Actions that aren't in the weights have a weight of 1.
+func WithWeights(weights map[string]float64) func(*Bot) {
+	return func(b *Bot) {
+		b.weights = weights
+	}
+}
+
+func New(opts ...func(*Bot)) *Bot {
+	b := &Bot{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *Bot) ChooseAction(gs truco.ClientGameState) truco.Action {
+	actions := []truco.Action{}
+	for _, a := range gs.PossibleActions {
+		action, err := truco.DeserializeAction(a)
+		if err != nil {
+			// Unreachable: possible actions are always valid.
+			continue
+		}
+		actions = append(actions, action)
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(actions))
+	total := 0.0
+	for i, action := range actions {
+		weights[i] = 1
+		if weight, ok := b.weights[action.GetName()]; ok {
+			weights[i] = weight
+		}
+		total += weights[i]
+	}
+	r := b.rand.Float64() * total
+	for i, weight := range weights {
+		if r -= weight; r < 0 {
+			return actions[i]
+		}
+	}
+	return actions[len(actions)-1]
+}
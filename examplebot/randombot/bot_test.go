@@ -0,0 +1,82 @@
+package randombot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChoosesPossibleActions plays games between random bots, and checks that they always choose
+// one of their possible actions.
+func TestChoosesPossibleActions(t *testing.T) {
+	configs := []struct {
+		name string
+		opts []func(*truco.GameState)
+	}{
+		{name: "two players with flor", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithFlorEnabled(true)}},
+		{name: "four players", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithPlayerCount(4)}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			for seed := int64(0); seed < 10; seed++ {
+				var (
+					gameState = truco.New(append(config.opts, truco.WithSeed(seed))...)
+					bots      = map[int]*Bot{}
+				)
+				for playerID := range gameState.Players {
+					bots[playerID] = New(WithSeed(seed), WithWeights(RareActionWeights))
+				}
+				for !gameState.IsGameEnded {
+					clientGameState := gameState.ToClientGameState(gameState.TurnPlayerID)
+					action := bots[gameState.TurnPlayerID].ChooseAction(clientGameState)
+					require.NotNil(t, action)
+					require.Contains(t, serializedActions(clientGameState.PossibleActions), string(truco.SerializeAction(action)))
+					require.NoError(t, gameState.RunAction(action))
+				}
+			}
+		})
+	}
+}
+
+// TestWeightsFavourActions checks that, over many draws, the bot chooses a weighted action more
+// often than any other, and more often than without weights.
+func TestWeightsFavourActions(t *testing.T) {
+	var (
+		clientGameState = truco.New(truco.WithSeed(1)).ToClientGameState(0)
+		faltaEnvido     string
+		uniform         = countChoices(New(WithSeed(1)), clientGameState)
+		weighted        = countChoices(New(WithSeed(1), WithWeights(map[string]float64{truco.SAY_FALTA_ENVIDO: 10})), clientGameState)
+	)
+	require.Len(t, uniform, len(clientGameState.PossibleActions))
+	for _, a := range clientGameState.PossibleActions {
+		if action, _ := truco.DeserializeAction(a); action.GetName() == truco.SAY_FALTA_ENVIDO {
+			faltaEnvido = string(a)
+		}
+	}
+	require.NotEmpty(t, faltaEnvido)
+	require.Greater(t, weighted[faltaEnvido], 3*uniform[faltaEnvido])
+	for action, count := range weighted {
+		if action != faltaEnvido {
+			require.Greater(t, weighted[faltaEnvido], 5*count, action)
+		}
+	}
+}
+
+// countChoices returns how many times the bot chooses each action over many draws.
+func countChoices(bot *Bot, gs truco.ClientGameState) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[string(truco.SerializeAction(bot.ChooseAction(gs)))]++
+	}
+	return counts
+}
+
+func serializedActions(actions []json.RawMessage) []string {
+	result := []string{}
+	for _, a := range actions {
+		result = append(result, string(a))
+	}
+	return result
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/marianogappa/truco/server"
 	"github.com/marianogappa/truco/truco"
 	"github.com/nsf/termbox-go"
 )
@@ -104,8 +105,8 @@ func renderScores(rs renderState) {
 		themMano = " (mano)"
 	}
 
-	renderUpToAt(rs.viewportWidth-1, 1, fmt.Sprintf("Vos%v %v", youMano, spanishScore(rs.gs.YourScore)))
-	renderUpToAt(rs.viewportWidth-1, 2, fmt.Sprintf("Elle%v %v", themMano, spanishScore(rs.gs.TheirScore)))
+	renderUpToAt(rs.viewportWidth-1, 1, fmt.Sprintf("Vos%v %v", youMano, spanishScore(rs.gs.YourScore, rs.gs.YourStage, rs.gs.Rules)))
+	renderUpToAt(rs.viewportWidth-1, 2, fmt.Sprintf("Elle%v %v", themMano, spanishScore(rs.gs.TheirScore, rs.gs.TheirStage, rs.gs.Rules)))
 }
 
 func renderTheirUnrevealedCards(rs renderState) {
@@ -135,30 +136,14 @@ func renderEndSummary(rs renderState) {
 
 	switch rs.mode {
 	case PRINT_MODE_SHOW_ROUND_RESULT:
-		envidoPart := "el envido no se jugó"
-		if rs.gs.EnvidoWinnerPlayerID != -1 {
-			envidoWinner := "vos"
-			won := "ganaste"
-			if rs.gs.EnvidoWinnerPlayerID == rs.gs.ThemPlayerID {
-				envidoWinner = "elle"
-				won = "ganó"
-			}
-			envidoPart = fmt.Sprintf("%v %v %v puntos por el envido", envidoWinner, won, rs.gs.EnvidoPoints)
+		parts := []string{}
+		for _, scoreLog := range rs.gs.ScoresLog {
+			parts = append(parts, spanishScoreLog(scoreLog, rs.gs.YourTeamID))
 		}
-		trucoWinner := "vos"
-		won := "ganaste"
-		if rs.gs.TrucoWinnerPlayerID == rs.gs.ThemPlayerID {
-			trucoWinner = "elle"
-			won = "ganó"
+		if len(parts) == 0 {
+			parts = append(parts, "nadie sumó puntos")
 		}
-
-		renderText = fmt.Sprintf(
-			"Terminó la mano, %v y %v %v %v puntos por el truco.",
-			envidoPart,
-			trucoWinner,
-			won,
-			rs.gs.TrucoPoints,
-		)
+		renderText = fmt.Sprintf("Terminó la mano: %v.", strings.Join(parts, ", "))
 	case PRINT_MODE_END:
 		var resultText string
 		if rs.gs.YouPlayerID == rs.gs.WinnerPlayerID {
@@ -210,6 +195,13 @@ func renderActions(rs renderState) {
 	renderAt(0, rs.viewportHeight-2, renderText)
 }
 
+// renderError shows the error on the bottom line, on top of the current render.
+func (u *ui) renderError(msgErr server.MessageError) {
+	_, viewportHeight := termbox.Size()
+	renderAt(0, viewportHeight-1, spanishError(msgErr))
+	termbox.Flush()
+}
+
 func renderAt(x, y int, s string) {
 	_s := []rune(s)
 	for i, r := range _s {
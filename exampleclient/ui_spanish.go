@@ -6,6 +6,7 @@ package exampleclient
 import (
 	"fmt"
 
+	"github.com/marianogappa/truco/server"
 	"github.com/marianogappa/truco/truco"
 )
 
@@ -25,11 +26,13 @@ func getActionString(log truco.ActionLog, playerID int) string {
 
 	said := "dijiste"
 	revealed := "tiraste"
+	forfeited := "abandonaste"
 	who := "Vos"
 	if playerID != log.PlayerID {
 		who = "Elle"
 		said = "dijo"
 		revealed = "tiró"
+		forfeited = "abandonó"
 	}
 
 	var what string
@@ -72,6 +75,8 @@ func getActionString(log truco.ActionLog, playerID int) string {
 		what = fmt.Sprintf("%v en mesa", _action.Score)
 	case truco.CONFIRM_ROUND_FINISHED:
 		what = ""
+	case truco.FORFEIT:
+		what = fmt.Sprintf("%v la partida", forfeited)
 	default:
 		what = "???"
 	}
@@ -79,17 +84,62 @@ func getActionString(log truco.ActionLog, playerID int) string {
 	return fmt.Sprintf("%v %v\n", who, what)
 }
 
-func spanishScore(score int) string {
-	if score == 1 {
-		return "1 mala"
+func spanishScore(score int, stage string, rules truco.Rules) string {
+	// Games without malas are counted from 0 to the end
+	if rules.MalasPoints() == 0 {
+		if score == 1 {
+			return "1 buena"
+		}
+		return fmt.Sprintf("%d buenas", score)
 	}
-	if score < 15 {
+	if stage == truco.STAGE_MALAS {
+		if score == 1 {
+			return "1 mala"
+		}
 		return fmt.Sprintf("%d malas", score)
 	}
-	if score == 15 {
+	buenas := score - rules.MalasPoints()
+	switch buenas {
+	case 0:
 		return "entraste"
+	case 1:
+		return "1 buena"
+	default:
+		return fmt.Sprintf("%d buenas", buenas)
+	}
+}
+
+// spanishScoreLog explains why some points were awarded, e.g. "vos ganaste 2 puntos por el envido".
+func spanishScoreLog(scoreLog truco.ScoreLog, yourTeamID int) string {
+	who, won := "vos", "ganaste"
+	if scoreLog.TeamID != yourTeamID {
+		who, won = "elle", "ganó"
+	}
+	points := fmt.Sprintf("%d puntos", scoreLog.Points)
+	if scoreLog.Points == 1 {
+		points = "1 punto"
+	}
+	reason := map[string]string{
+		truco.POINTS_REASON_ENVIDO:  "por el envido",
+		truco.POINTS_REASON_FLOR:    "por la flor",
+		truco.POINTS_REASON_TRUCO:   "por el truco",
+		truco.POINTS_REASON_MAZO:    "porque el otro se fue al mazo",
+		truco.POINTS_REASON_FORFEIT: "por abandono",
+	}[scoreLog.Reason]
+	return fmt.Sprintf("%v %v %v %v", who, won, points, reason)
+}
+
+func spanishError(msgErr server.MessageError) string {
+	switch msgErr.Code {
+	case server.ErrorCodeNotYourTurn:
+		return "¡No es tu turno!"
+	case server.ErrorCodeActionNotPossible:
+		return "¡No podés hacer eso ahora!"
+	case server.ErrorCodeGameIsEnded:
+		return "¡El juego ya terminó!"
+	default:
+		return fmt.Sprintf("Error: %v", msgErr.Message)
 	}
-	return fmt.Sprintf("%d buenas", score-15)
 }
 
 func spanishAction(action truco.Action) string {
@@ -129,6 +179,8 @@ func spanishAction(action truco.Action) string {
 		return "me voy al mazo"
 	case truco.CONFIRM_ROUND_FINISHED:
 		return "seguir"
+	case truco.FORFEIT:
+		return "abandonar"
 	case truco.REVEAL_ENVIDO_SCORE:
 		_action := action.(*truco.ActionRevealEnvidoScore)
 		return fmt.Sprintf("mostrar las %v", _action.Score)
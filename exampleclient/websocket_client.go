@@ -13,11 +13,11 @@ import (
 	"github.com/marianogappa/truco/truco"
 )
 
-func Player(playerID int, address string) {
+func Player(playerID int, address string, gameID string) {
 	var (
-		ui          = NewUI()
-		conn        = handshakeWithServer(playerID, address)
-		gameStateCh = recvGameState(conn)
+		ui                   = NewUI()
+		conn                 = handshakeWithServer(playerID, address, gameID)
+		gameStateCh, errorCh = recvMessages(conn)
 
 		clientGameState truco.ClientGameState
 		possibleActions []truco.Action
@@ -31,6 +31,10 @@ func Player(playerID int, address string) {
 			if err := ui.render(clientGameState); err != nil {
 				log.Fatal(err)
 			}
+		case msgErr := <-errorCh:
+			// The server rejected the last action (e.g. it was based on a stale game state).
+			// The current game state is still valid, so just let the player know.
+			ui.renderError(msgErr)
 		case key := <-ui.keyCh:
 			// If game is over, finish after any key press.
 			if clientGameState.IsGameEnded {
@@ -59,7 +63,7 @@ func Player(playerID int, address string) {
 	}
 }
 
-func handshakeWithServer(playerID int, address string) *websocket.Conn {
+func handshakeWithServer(playerID int, address string, gameID string) *websocket.Conn {
 	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%v/ws", address), nil)
 	if err != nil {
 		log.Fatalf("Failed to connect to WebSocket server: %v", err)
@@ -67,23 +71,39 @@ func handshakeWithServer(playerID int, address string) *websocket.Conn {
 
 	// Hello message is meant to tell the server who we are, and request game state.
 	// Game could be in progress (this could be a reconnection).
-	if err := server.WsSend(conn, server.NewMessageHello(playerID)); err != nil {
+	if err := server.WsSend(conn, server.NewMessageHello(gameID, playerID)); err != nil {
 		log.Fatal(err)
 	}
 
 	return conn
 }
 
-func recvGameState(conn *websocket.Conn) chan truco.ClientGameState {
-	gameStateCh := make(chan truco.ClientGameState)
+func recvMessages(conn *websocket.Conn) (chan truco.ClientGameState, chan server.MessageError) {
+	var (
+		gameStateCh = make(chan truco.ClientGameState)
+		errorCh     = make(chan server.MessageError)
+	)
 	go func() {
 		for {
-			clientGameState, err := server.WsReadMessage[truco.ClientGameState, server.MessageHeresGameState](conn, server.MessageTypeHeresGameState)
+			messageType, message, err := server.WsReadAnyMessage(conn)
 			if err != nil {
 				log.Fatal(err)
 			}
-			gameStateCh <- *clientGameState
+			switch messageType {
+			case server.MessageTypeHeresGameState:
+				clientGameState, err := server.WsDeserializeMessage[truco.ClientGameState, server.MessageHeresGameState](message, messageType)
+				if err != nil {
+					log.Fatal(err)
+				}
+				gameStateCh <- *clientGameState
+			case server.MessageTypeError:
+				msgErr, err := server.WsDeserializeMessage[server.MessageError, server.MessageError](message, messageType)
+				if err != nil {
+					log.Fatal(err)
+				}
+				errorCh <- *msgErr
+			}
 		}
 	}()
-	return gameStateCh
+	return gameStateCh, errorCh
 }
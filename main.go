@@ -4,14 +4,27 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/marianogappa/truco/botclient"
+	"github.com/marianogappa/truco/examplebot"
+	"github.com/marianogappa/truco/examplebot/envidocfr"
+	"github.com/marianogappa/truco/examplebot/mctsbot"
 	"github.com/marianogappa/truco/examplebot/newbot"
+	"github.com/marianogappa/truco/examplebot/randombot"
 	"github.com/marianogappa/truco/exampleclient"
 	"github.com/marianogappa/truco/server"
+	"github.com/marianogappa/truco/simulator"
+	"github.com/marianogappa/truco/truco"
 )
 
 func main() {
@@ -30,6 +43,11 @@ func main() {
 		address = os.Args[3]
 	}
 
+	gameID := server.DefaultGameID
+	if len(os.Args) >= 5 {
+		gameID = os.Args[4]
+	}
+
 	var (
 		playerNum int
 		err       error
@@ -44,11 +62,42 @@ func main() {
 
 	switch cmd {
 	case "server":
-		server.New(port).Start()
+		dataDir := os.Getenv("TRUCO_DATA_DIR")
+		if dataDir == "" {
+			server.New(port).Start()
+			return
+		}
+		storage, err := server.NewFileStorage(dataDir)
+		if err != nil {
+			fmt.Println("Invalid TRUCO_DATA_DIR:", err)
+			os.Exit(1)
+		}
+		server.New(port, server.WithStorage(storage)).Start()
 	case "player":
-		exampleclient.Player(playerNum-1, address)
+		exampleclient.Player(playerNum-1, address, gameID)
 	case "bot":
-		botclient.Bot(playerNum-1, address, newbot.New(newbot.WithDefaultLogger))
+		opts := []func(*newbot.Bot){newbot.WithDefaultLogger}
+		if path := os.Getenv("TRUCO_ENVIDO_STRATEGY"); path != "" {
+			strategy, err := envidocfr.Load(path)
+			if err != nil {
+				fmt.Println("Invalid TRUCO_ENVIDO_STRATEGY:", err)
+				os.Exit(1)
+			}
+			opts = append(opts, newbot.WithEnvidoStrategy(strategy))
+		}
+		var bot truco.Bot = newbot.New(opts...)
+		if os.Getenv("TRUCO_BOT") == "mcts" {
+			bot = mctsbot.New(mctsbot.WithDefaultLogger)
+		}
+		botclient.Bot(playerNum-1, address, gameID, bot)
+	case "train-envido":
+		trainEnvido(os.Args[2:])
+	case "simulate":
+		simulate(os.Args[2:])
+	case "tournament":
+		tournament(os.Args[2:])
+	case "fuzz":
+		fuzz(os.Args[2:])
 	default:
 		fmt.Println("Invalid argument. Please provide either server or client.")
 	}
@@ -56,13 +105,206 @@ func main() {
 
 func usage() {
 	fmt.Println("usage: truco server")
-	fmt.Println("usage: truco player %number [address]")
-	fmt.Println("usage: truco bot %number [address]")
+	fmt.Println("usage: truco player %number [address] [gameID]")
+	fmt.Println("usage: truco bot %number [address] [gameID]")
+	fmt.Println("usage: truco train-envido [iterations] [file]")
+	fmt.Println("usage: truco simulate [-games n] [-seed n] [-workers n] [-players n] [-max-points n] [-flor] bot1 bot2")
+	fmt.Println("usage: truco tournament [simulate flags] [-format round-robin|swiss] [-rounds n] [-json file] [-csv file] bot1 bot2 [bot...]")
+	fmt.Println("usage: truco fuzz [simulate flags]")
 	fmt.Println("usage: e.g. truco player 1")
 	fmt.Println("usage: e.g. truco player 2")
 	fmt.Println("usage: e.g. truco player 1 localhost:8080")
 	fmt.Println("usage: e.g. truco bot 1 localhost:8080")
 	fmt.Println("usage: e.g. truco bot 2")
+	fmt.Println("usage: e.g. truco player 1 localhost:8080 office-match")
+	fmt.Println("usage: e.g. truco train-envido 100000 envido.json")
+	fmt.Println("usage: e.g. truco simulate -games 1000 newbot examplebot")
+	fmt.Println("usage: e.g. truco tournament -games 200 -csv leaderboard.csv newbot examplebot random")
+	fmt.Println("usage: e.g. truco fuzz -games 1000000 -players 4")
+	fmt.Printf("Bots for truco simulate and truco tournament: %v.\n", strings.Join(contenderNames(), ", "))
 	fmt.Println("Define the PORT environment variable for truco server to change the default port (8080).")
+	fmt.Println("Define the TRUCO_DATA_DIR environment variable for truco server to save games there, and resume them after a restart.")
+	fmt.Println("Define the TRUCO_BOT environment variable as mcts for truco bot to play with the Monte Carlo Tree Search bot.")
+	fmt.Println("Define the TRUCO_ENVIDO_STRATEGY environment variable as a file saved by truco train-envido for truco bot to bid envido with it.")
 	os.Exit(1)
 }
+
+// trainEnvido trains an envido strategy with CFR and saves it, then prints how exploitable it is
+// in each score situation, compared with the example bot's envido thresholds.
+func trainEnvido(args []string) {
+	var (
+		iterations = 100000
+		path       = "envido.json"
+		err        error
+	)
+	if len(args) >= 1 {
+		if iterations, err = strconv.Atoi(args[0]); err != nil || iterations <= 0 {
+			fmt.Println("Invalid number of iterations. Please provide a positive number.")
+			usage()
+		}
+	}
+	if len(args) >= 2 {
+		path = args[1]
+	}
+
+	rules := truco.New().Rules
+	fmt.Printf("Training envido strategy with %v iterations per score situation...\n", iterations)
+	strategy := envidocfr.Train(rules, iterations, time.Now().UnixNano())
+	if err := strategy.Save(path); err != nil {
+		fmt.Println("Couldn't save envido strategy:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved envido strategy to %v.\n", path)
+
+	fmt.Println("Exploitability (points per envido that a best response wins):")
+	thresholds := newbot.EnvidoPolicy(rules, 100)
+	for _, situation := range envidocfr.Situations(rules) {
+		fmt.Printf("mano %2d, pie %2d: CFR %.3f, bot thresholds %.3f\n",
+			situation.ManoScore, situation.PieScore,
+			envidocfr.Exploitability(rules, strategy.Probabilities, situation),
+			envidocfr.Exploitability(rules, thresholds, situation),
+		)
+	}
+}
+
+// contenders are the bots that can play in truco simulate and truco tournament.
+var contenders = map[string]func(seed int64) truco.Bot{
+	"newbot":     func(int64) truco.Bot { return newbot.New() },
+	"examplebot": func(int64) truco.Bot { return examplebot.New() },
+	"random":     func(seed int64) truco.Bot { return randombot.New(randombot.WithSeed(seed)) },
+	"weighted": func(seed int64) truco.Bot {
+		return randombot.New(randombot.WithSeed(seed), randombot.WithWeights(randombot.RareActionWeights))
+	},
+	"mcts": func(seed int64) truco.Bot { return mctsbot.New(mctsbot.WithIterations(100), mctsbot.WithSeed(seed)) },
+}
+
+func contenderNames() []string {
+	names := []string{}
+	for name := range contenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// simulatorFlags defines the flags of the simulator on the flag set, and returns a function that
+// makes the simulator out of them, and any other options, once they are parsed. It exits with the
+// usage if the number of players or the max points aren't supported.
+func simulatorFlags(flags *flag.FlagSet, gamesUsage string) func(...func(*simulator.Simulator)) *simulator.Simulator {
+	var (
+		games     = flags.Int("games", simulator.DefaultGames, gamesUsage)
+		seed      = flags.Int64("seed", 1, "seed of the first game")
+		workers   = flags.Int("workers", runtime.NumCPU(), "number of games to play in parallel")
+		players   = flags.Int("players", 2, "number of players of each game: 2, 4 or 6")
+		maxPoints = flags.Int("max-points", 30, "points to win a game: 15 or 30")
+		flor      = flags.Bool("flor", false, "play with flor")
+	)
+	return func(opts ...func(*simulator.Simulator)) *simulator.Simulator {
+		if *players != 2 && *players != 4 && *players != 6 {
+			fmt.Printf("Invalid number of players %v. Please choose 2, 4 or 6.\n", *players)
+			usage()
+		}
+		if *maxPoints != 15 && *maxPoints != 30 {
+			fmt.Printf("Invalid max points %v. Please choose 15 or 30.\n", *maxPoints)
+			usage()
+		}
+		return simulator.New(append([]func(*simulator.Simulator){
+			simulator.WithGames(*games),
+			simulator.WithSeed(*seed),
+			simulator.WithWorkers(*workers),
+			simulator.WithGameOptions(truco.WithPlayerCount(*players), truco.WithMaxPoints(*maxPoints), truco.WithFlorEnabled(*flor)),
+		}, opts...)...)
+	}
+}
+
+// namedContenders returns the contenders with the given names, or exits if any is unknown.
+func namedContenders(names []string) []simulator.Contender {
+	result := []simulator.Contender{}
+	for _, name := range names {
+		newBot, ok := contenders[name]
+		if !ok {
+			fmt.Printf("Unknown bot %v. Please choose one of %v.\n", name, strings.Join(contenderNames(), ", "))
+			os.Exit(1)
+		}
+		result = append(result, simulator.Contender{Name: name, New: newBot})
+	}
+	// The legacy example bot logs every decision.
+	log.SetOutput(io.Discard)
+	return result
+}
+
+// simulate plays games between two bots in-process, and prints how each of them did.
+func simulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	newSimulator := simulatorFlags(flags, "number of games to play")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		usage()
+	}
+	simulated := namedContenders(flags.Args())
+	fmt.Print(newSimulator().Run(simulated[0], simulated[1]))
+}
+
+// tournament plays a tournament between bots in-process, prints the leaderboard, and optionally
+// exports the results.
+func tournament(args []string) {
+	var (
+		flags        = flag.NewFlagSet("tournament", flag.ExitOnError)
+		newSimulator = simulatorFlags(flags, "number of games of each match")
+		format       = flags.String("format", simulator.RoundRobin, "tournament format: round-robin or swiss")
+		rounds       = flags.Int("rounds", 0, "number of rounds of a swiss tournament (default log2 of the number of bots)")
+		jsonPath     = flags.String("json", "", "file to export the whole results to, as JSON")
+		csvPath      = flags.String("csv", "", "file to export the leaderboard to, as CSV")
+	)
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		usage()
+	}
+	result, err := simulator.NewTournament(newSimulator(), simulator.WithFormat(*format), simulator.WithRounds(*rounds)).Run(namedContenders(flags.Args()))
+	if err != nil {
+		fmt.Println("Invalid tournament:", err)
+		os.Exit(1)
+	}
+	fmt.Print(result)
+
+	exports := []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{*jsonPath, result.WriteJSON},
+		{*csvPath, result.WriteCSV},
+	}
+	for _, export := range exports {
+		if export.path == "" {
+			continue
+		}
+		f, err := os.Create(export.path)
+		if err == nil {
+			err = export.write(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			fmt.Println("Couldn't export the results:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// fuzz plays games between random bots, checking the engine's invariants after every action, and
+// exits with an error if any game broke them.
+func fuzz(args []string) {
+	flags := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	newSimulator := simulatorFlags(flags, "number of games to play")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		usage()
+	}
+	fuzzed := namedContenders([]string{"random", "weighted"})
+	report := newSimulator(simulator.WithInvariantChecks(true)).Run(fuzzed[0], fuzzed[1])
+	fmt.Print(report)
+	if report.Finished != report.Games {
+		os.Exit(1)
+	}
+}
@@ -24,26 +24,15 @@ var (
 	bot   truco.Bot
 )
 
-type rules struct {
-	MaxPoints     int  `json:"maxPoints"`
-	IsFlorEnabled bool `json:"isFlorEnabled"`
-}
-
 func trucoNew(this js.Value, p []js.Value) interface{} {
 	jsonBytes := make([]byte, p[0].Length())
 	js.CopyBytesToGo(jsonBytes, p[0])
-	var r rules
-	// ignore rules if unmarshal fails
-	_ = json.Unmarshal(jsonBytes, &r)
-
-	opts := []func(*truco.GameState){}
-	if r.MaxPoints > 0 {
-		opts = append(opts, truco.WithMaxPoints(r.MaxPoints))
-	}
-	if r.IsFlorEnabled {
-		opts = append(opts, truco.WithFlorEnabled(r.IsFlorEnabled))
+	var r truco.Rules
+	// ignore rules if unmarshal fails or they are invalid
+	if err := json.Unmarshal(jsonBytes, &r); err != nil || r.Validate() != nil {
+		r = truco.Rules{}
 	}
-	state = truco.New(opts...)
+	state = truco.New(truco.WithRules(r))
 
 	bot = (newbot.New())
 
@@ -77,6 +66,9 @@ func trucoBotRunAction(this js.Value, p []js.Value) interface{} {
 		if err != nil {
 			panic(fmt.Errorf("running action: %w", err))
 		}
+	} else {
+		// No action was run, so the events of the last one mustn't be returned again.
+		state.Events = nil
 	}
 
 	nbs, err := json.Marshal(state.ToClientGameState(0))
@@ -0,0 +1,142 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// ClockRules limit how long players may take to act, so that a player who walks away doesn't
+// block the game forever. Times are in milliseconds; 0 means no limit.
+type ClockRules struct {
+	// PerMoveMs is the time a player has for each of their moves.
+	PerMoveMs int64 `json:"perMoveMs"`
+
+	// TotalMs is the time a player has for all of their moves in the game.
+	TotalMs int64 `json:"totalMs"`
+
+	// IncrementMs is added to a player's total time after each of their moves (Fischer-style).
+	IncrementMs int64 `json:"incrementMs"`
+
+	// OnTimeout is what happens when a player runs out of time: TimeoutMazo (the default) or
+	// TimeoutForfeit. Once a player's total time runs out, every one of their turns times out.
+	OnTimeout string `json:"onTimeout"`
+}
+
+const (
+	// TimeoutMazo makes the player go to the mazo. If they can't right now (e.g. they must
+	// answer an envido first), their first possible action is run instead.
+	TimeoutMazo = "mazo"
+
+	// TimeoutForfeit makes the player lose the game.
+	TimeoutForfeit = "forfeit"
+)
+
+var errInvalidClockRules = errors.New("invalid clock rules")
+
+func (r ClockRules) validate() error {
+	if r.PerMoveMs < 0 || r.TotalMs < 0 || r.IncrementMs < 0 {
+		return errInvalidClockRules
+	}
+	if r.PerMoveMs == 0 && r.TotalMs == 0 {
+		return errInvalidClockRules
+	}
+	if r.IncrementMs > 0 && r.TotalMs == 0 {
+		return errInvalidClockRules
+	}
+	switch r.OnTimeout {
+	case "", TimeoutMazo, TimeoutForfeit:
+		return nil
+	default:
+		return errInvalidClockRules
+	}
+}
+
+// gameClock keeps track of the players' time. It's owned by the game's goroutine.
+//
+// Only one player's clock runs at a time: the one whose turn it is. It doesn't start until
+// all players joined, and it isn't persisted, so it starts over if the server restarts.
+type gameClock struct {
+	rules     ClockRules
+	remaining []time.Duration // total time left per player, if there's a total limit
+
+	runningFor int // -1 if no clock is running
+	startedAt  time.Time
+	moveLimit  time.Duration // for the running move
+	timer      *time.Timer
+}
+
+func newGameClock(rules ClockRules, playerCount int) *gameClock {
+	c := &gameClock{rules: rules, remaining: make([]time.Duration, playerCount), runningFor: -1}
+	for i := range c.remaining {
+		c.remaining[i] = time.Duration(rules.TotalMs) * time.Millisecond
+	}
+	return c
+}
+
+func (c *gameClock) isStarted() bool {
+	return c.runningFor >= 0
+}
+
+// start runs the given player's clock. It must be stopped first.
+func (c *gameClock) start(playerID int, now time.Time) {
+	c.runningFor = playerID
+	c.startedAt = now
+	c.moveLimit = time.Duration(c.rules.PerMoveMs) * time.Millisecond
+	if c.rules.TotalMs > 0 && (c.moveLimit == 0 || c.remaining[playerID] < c.moveLimit) {
+		c.moveLimit = c.remaining[playerID]
+	}
+	c.timer = time.NewTimer(c.moveLimit)
+}
+
+// stop charges the elapsed time to the player whose clock was running, plus the increment
+// if they were the one who moved.
+func (c *gameClock) stop(now time.Time, movedPlayerID int) {
+	if !c.isStarted() {
+		return
+	}
+	c.timer.Stop()
+	c.timer = nil
+	if c.rules.TotalMs > 0 {
+		c.remaining[c.runningFor] = c.remainingAt(c.runningFor, now)
+		if movedPlayerID == c.runningFor {
+			c.remaining[c.runningFor] += time.Duration(c.rules.IncrementMs) * time.Millisecond
+		}
+	}
+	c.runningFor = -1
+}
+
+// expired fires when the running player runs out of time. It's nil if no clock is running.
+func (c *gameClock) expired() <-chan time.Time {
+	if c == nil || c.timer == nil {
+		return nil
+	}
+	return c.timer.C
+}
+
+func (c *gameClock) remainingAt(playerID int, now time.Time) time.Duration {
+	remaining := c.remaining[playerID]
+	if playerID == c.runningFor {
+		remaining -= now.Sub(c.startedAt)
+	}
+	return max(remaining, 0)
+}
+
+func (c *gameClock) toClientClock(youPlayerID int, themPlayerID int, now time.Time) *truco.ClientClock {
+	if c == nil {
+		return nil
+	}
+	clock := &truco.ClientClock{RunningForPlayerID: c.runningFor, YourRemainingMs: -1, TheirRemainingMs: -1, MoveRemainingMs: -1}
+	if c.rules.TotalMs > 0 {
+		clock.YourRemainingMs = c.remainingAt(youPlayerID, now).Milliseconds()
+		clock.TheirRemainingMs = c.remainingAt(themPlayerID, now).Milliseconds()
+	}
+	if c.isStarted() {
+		clock.MoveRemainingMs = max(c.moveLimit-now.Sub(c.startedAt), 0).Milliseconds()
+	}
+	return clock
+}
@@ -0,0 +1,140 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutGoesToTheMazo(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("ladder", GameRules{Clock: &ClockRules{PerMoveMs: 50}})
+	require.NoError(t, err)
+
+	// The clock doesn't run until both players joined.
+	p0 := dialPlayer(t, url, "ladder", 0)
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, &truco.ClientClock{RunningForPlayerID: -1, YourRemainingMs: -1, TheirRemainingMs: -1, MoveRemainingMs: -1}, state.Clock)
+
+	p1 := dialPlayer(t, url, "ladder", 1)
+	state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, 0, state.Clock.RunningForPlayerID)
+	require.LessOrEqual(t, state.Clock.MoveRemainingMs, int64(50))
+	require.Equal(t, int64(-1), state.Clock.YourRemainingMs)
+
+	// Player 0 doesn't act, so they go to the mazo, and it's their turn to confirm the round finished.
+	state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, 0, state.LastActionLog.PlayerID)
+	action, err := truco.DeserializeAction(state.LastActionLog.Action)
+	require.NoError(t, err)
+	require.Equal(t, truco.SAY_ME_VOY_AL_MAZO, action.GetName())
+	require.True(t, state.IsRoundFinished)
+	require.Equal(t, 2, state.YourScore)
+}
+
+func TestTimeoutActionsCantBeTakenBack(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("casual", GameRules{IsTakeBackEnabled: true, Clock: &ClockRules{PerMoveMs: 200}})
+	require.NoError(t, err)
+
+	p0 := dialPlayer(t, url, "casual", 0)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	p1 := dialPlayer(t, url, "casual", 1)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	// Player 0 goes to the mazo on timeout, and can't undo it.
+	for {
+		state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+		require.NoError(t, err)
+		if state.LastActionLog != nil {
+			require.Equal(t, 0, state.LastActionLog.PlayerID)
+			break
+		}
+	}
+	require.NoError(t, WsSend(p0, NewMessageTakeBackRequest(0)))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeTakeBackNotAllowed, msgErr.Code)
+}
+
+func TestTimeoutForfeitsTheGame(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("ladder", GameRules{Clock: &ClockRules{TotalMs: 50, OnTimeout: TimeoutForfeit}})
+	require.NoError(t, err)
+
+	p0 := dialPlayer(t, url, "ladder", 0)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	p1 := dialPlayer(t, url, "ladder", 1)
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.InDelta(t, 50, state.Clock.TheirRemainingMs, 10)
+
+	state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.True(t, state.IsGameEnded)
+	require.Equal(t, 1, state.WinnerPlayerID)
+	require.Equal(t, -1, state.Clock.RunningForPlayerID)
+	require.Equal(t, int64(0), state.Clock.TheirRemainingMs)
+}
+
+func TestTimeoutActionWhenMazoIsNotPossible(t *testing.T) {
+	g := &game{rules: GameRules{Clock: &ClockRules{PerMoveMs: 1000}}, gameState: truco.New()}
+	require.NoError(t, g.gameState.RunAction(truco.NewActionSayEnvido(0)))
+
+	// Player 1 must answer the envido before going to the mazo.
+	action := g.timeoutAction(1)
+	require.Equal(t, 1, action.GetPlayerID())
+	require.NotEqual(t, truco.SAY_ME_VOY_AL_MAZO, action.GetName())
+	require.NoError(t, g.gameState.RunAction(action))
+}
+
+func TestClockIncrement(t *testing.T) {
+	c := newGameClock(ClockRules{PerMoveMs: 500, TotalMs: 1000, IncrementMs: 100}, 2)
+	now := time.Now()
+
+	c.start(0, now)
+	require.Equal(t, 500*time.Millisecond, c.moveLimit)
+	now = now.Add(300 * time.Millisecond)
+	require.Equal(t, &truco.ClientClock{RunningForPlayerID: 0, YourRemainingMs: 700, TheirRemainingMs: 1000, MoveRemainingMs: 200}, c.toClientClock(0, 1, now))
+
+	// Player 0 moved, so they get the increment.
+	c.stop(now, 0)
+	require.Equal(t, 800*time.Millisecond, c.remaining[0])
+
+	// Player 1 timed out, so they don't, and their next move is limited by their total time.
+	c.start(1, now)
+	now = now.Add(500 * time.Millisecond)
+	c.stop(now, -1)
+	require.Equal(t, 500*time.Millisecond, c.remaining[1])
+	c.start(1, now)
+	now = now.Add(700 * time.Millisecond)
+	c.stop(now, -1)
+	require.Equal(t, time.Duration(0), c.remaining[1])
+	c.start(1, now)
+	require.Equal(t, time.Duration(0), c.moveLimit)
+	c.stop(now, -1)
+}
+
+func TestInvalidClockRules(t *testing.T) {
+	s := New("0")
+	for _, rules := range []ClockRules{
+		{},
+		{PerMoveMs: -1},
+		{PerMoveMs: 1000, IncrementMs: 100},
+		{PerMoveMs: 1000, OnTimeout: "cry"},
+	} {
+		_, err := s.createGame("", GameRules{Clock: &rules})
+		require.ErrorIs(t, err, errInvalidClockRules, "%+v", rules)
+	}
+}
@@ -0,0 +1,575 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/marianogappa/truco/truco"
+)
+
+// GameRules are the rules a game is created with. They map to truco.GameState options.
+type GameRules struct {
+	MaxPoints     int  `json:"maxPoints"`
+	IsFlorEnabled bool `json:"isFlorEnabled"`
+
+	// PlayerCount is 2 (the default), 4 or 6, for 2 vs 2 or 3 vs 3 team games. Flor isn't played in team games.
+	PlayerCount int `json:"playerCount,omitempty"`
+
+	// PicaPicaFrom and PicaPicaTo enable pica-pica rounds in 6 player games, as in truco.WithPicaPica.
+	PicaPicaFrom int `json:"picaPicaFrom,omitempty"`
+	PicaPicaTo   int `json:"picaPicaTo,omitempty"`
+
+	// House rule variants, as in truco.Rules.
+	IsContraflorDisabled           bool   `json:"isContraflorDisabled,omitempty"`
+	IsEnvidoKeptAfterFlor          bool   `json:"isEnvidoKeptAfterFlor,omitempty"`
+	IsRealEnvidoRepeatable         bool   `json:"isRealEnvidoRepeatable,omitempty"`
+	IsEnvidoAllowedInSecondFaceoff bool   `json:"isEnvidoAllowedInSecondFaceoff,omitempty"`
+	FaltaEnvido                    string `json:"faltaEnvido,omitempty"`
+	Mazo                           string `json:"mazo,omitempty"`
+	IsMazoForbiddenDuringEnvido    bool   `json:"isMazoForbiddenDuringEnvido,omitempty"`
+
+	// IsTakeBackEnabled allows players to take back their last action, if all their opponents agree.
+	// It's a server rule rather than a truco one, meant for casual games.
+	IsTakeBackEnabled bool `json:"isTakeBackEnabled"`
+
+	// Clock limits how long players may take to act. If nil, they may take as long as they want.
+	Clock *ClockRules `json:"clock,omitempty"`
+}
+
+var (
+	errInvalidPlayerCount = errors.New("invalid player count: must be 2, 4 or 6")
+	errInvalidPicaPica    = errors.New("invalid pica-pica range: it needs 6 players, and 0 <= from < to")
+)
+
+func (r GameRules) validate() error {
+	if r.PlayerCount != 0 && r.PlayerCount != 2 && r.PlayerCount != 4 && r.PlayerCount != 6 {
+		return errInvalidPlayerCount
+	}
+	if (r.PicaPicaFrom != 0 || r.PicaPicaTo != 0) && (r.PlayerCount != 6 || r.PicaPicaFrom < 0 || r.PicaPicaTo <= r.PicaPicaFrom) {
+		return errInvalidPicaPica
+	}
+	if err := r.trucoRules().Validate(); err != nil {
+		return err
+	}
+	if r.Clock != nil {
+		return r.Clock.validate()
+	}
+	return nil
+}
+
+func (r GameRules) options() []func(*truco.GameState) {
+	opts := []func(*truco.GameState){}
+	if r.PlayerCount > 0 {
+		opts = append(opts, truco.WithPlayerCount(r.PlayerCount))
+	}
+	return append(opts, truco.WithRules(r.trucoRules()))
+}
+
+func (r GameRules) trucoRules() truco.Rules {
+	return truco.Rules{
+		MaxPoints:                      r.MaxPoints,
+		IsFlorEnabled:                  r.IsFlorEnabled,
+		IsContraflorDisabled:           r.IsContraflorDisabled,
+		IsEnvidoKeptAfterFlor:          r.IsEnvidoKeptAfterFlor,
+		IsRealEnvidoRepeatable:         r.IsRealEnvidoRepeatable,
+		IsEnvidoAllowedInSecondFaceoff: r.IsEnvidoAllowedInSecondFaceoff,
+		FaltaEnvido:                    r.FaltaEnvido,
+		Mazo:                           r.Mazo,
+		IsMazoForbiddenDuringEnvido:    r.IsMazoForbiddenDuringEnvido,
+		PicaPicaFrom:                   r.PicaPicaFrom,
+		PicaPicaTo:                     r.PicaPicaTo,
+	}
+}
+
+// game is a single match hosted by the server.
+//
+// Each game is owned by a single goroutine (see run), which is the only one that ever touches
+// the game state and the player slots. Connections talk to it exclusively through channels, so
+// actions, joins, leaves and broadcasts are serialised in the order the game receives them.
+type game struct {
+	id        string
+	rules     GameRules
+	createdAt time.Time
+	storage   Storage // nil if games aren't persisted
+
+	// Owned by the game's goroutine.
+	gameState *truco.GameState
+	players   []*client
+
+	// takeBack is the state before the last action, which may be taken back by the player who
+	// ran it. It's nil if take-backs are disabled, or if there's nothing to take back.
+	takeBack *takeBack
+
+	clock *gameClock // nil if the game has no clock
+
+	joins    chan joinRequest
+	leaves   chan *client
+	messages chan clientMessage
+	calls    chan func()
+	stopped  bool
+
+	// done is closed when the game's goroutine exits. Anyone sending to the game must also
+	// select on it, so that they don't block forever on a collected game.
+	done chan struct{}
+}
+
+type joinRequest struct {
+	client *client
+	result chan error
+}
+
+type takeBack struct {
+	gameState   *truco.GameState
+	playerID    int  // who ran the action that would be taken back
+	isRequested bool // whether the player asked to take it back, and is waiting for the opponents
+
+	// acceptedBy has the opponents who accepted the request so far. All of them must accept it.
+	acceptedBy map[int]bool
+}
+
+// clientMessage is a message received from a client, already deserialized by its connection's goroutine.
+type clientMessage struct {
+	client      *client
+	messageType int
+	action      truco.Action
+	accepted    bool // for take-back responses
+
+	// rejection, if set, means the connection rejected the message, and the game should only
+	// reply with it, since it's the only one allowed to write to the client.
+	rejection *MessageError
+}
+
+func newGame(id string, rules GameRules, storage Storage) *game {
+	g := &game{
+		id:        id,
+		rules:     rules,
+		createdAt: time.Now(),
+		storage:   storage,
+		gameState: truco.New(rules.options()...),
+	}
+	g.gameState.Events = nil // nobody is connected to see the first round start
+	// The seed, together with the actions in the log, is enough to reproduce the game, e.g. on a bug report.
+	log.Println("Created game", id, "with seed", g.gameState.Seed)
+	g.save()
+	return g.start()
+}
+
+// restoreGame resumes a game from its last snapshot.
+func restoreGame(stored StoredGame, storage Storage) (*game, error) {
+	gameState, err := truco.DeserializeGameState(stored.GameState)
+	if err != nil {
+		return nil, fmt.Errorf("restoring game %v: %w", stored.ID, err)
+	}
+	g := &game{
+		id:        stored.ID,
+		rules:     stored.Rules,
+		createdAt: stored.CreatedAt,
+		storage:   storage,
+		gameState: gameState,
+	}
+	return g.start(), nil
+}
+
+func (g *game) start() *game {
+	g.players = make([]*client, len(g.gameState.Players))
+	g.joins = make(chan joinRequest)
+	g.leaves = make(chan *client)
+	g.messages = make(chan clientMessage)
+	g.calls = make(chan func())
+	g.done = make(chan struct{})
+	if g.rules.Clock != nil {
+		g.clock = newGameClock(*g.rules.Clock, len(g.players))
+	}
+	go g.run()
+	return g
+}
+
+// save snapshots the game to storage, if any. Failing to save isn't fatal to the game, which
+// can still be played; it would only be lost on a restart.
+func (g *game) save() {
+	if g.storage == nil {
+		return
+	}
+	bs, err := g.gameState.Serialize()
+	if err == nil {
+		err = g.storage.Save(StoredGame{ID: g.id, Rules: g.rules, CreatedAt: g.createdAt, GameState: bs})
+	}
+	if err != nil {
+		log.Println("Failed to save game", g.id, ":", err)
+	}
+}
+
+var (
+	errInvalidPlayerID        = errors.New("invalid player ID")
+	errPlayerAlreadyConnected = errors.New("player already connected")
+)
+
+func (g *game) run() {
+	defer close(g.done)
+	for !g.stopped {
+		select {
+		case req := <-g.joins:
+			req.result <- g.handleJoin(req.client)
+		case c := <-g.leaves:
+			g.handleLeave(c)
+		case msg := <-g.messages:
+			g.handleMessage(msg)
+		case fn := <-g.calls:
+			fn()
+		case <-g.clock.expired():
+			g.handleTimeout()
+		}
+	}
+}
+
+// join takes the player's slot and sends them the current game state.
+func (g *game) join(c *client) error {
+	req := joinRequest{client: c, result: make(chan error, 1)}
+	select {
+	case g.joins <- req:
+		return <-req.result
+	case <-g.done:
+		return errGameNotFound
+	}
+}
+
+// leave frees the player's slot and closes the client's outbox.
+func (g *game) leave(c *client) {
+	select {
+	case g.leaves <- c:
+	case <-g.done:
+		c.close()
+	}
+}
+
+// send hands a client message over to the game's goroutine. It returns false if the game is gone.
+func (g *game) send(msg clientMessage) bool {
+	select {
+	case g.messages <- msg:
+		return true
+	case <-g.done:
+		return false
+	}
+}
+
+// do runs fn on the game's goroutine and waits for it to finish. It returns false if the game is gone.
+func (g *game) do(fn func()) bool {
+	finished := make(chan struct{})
+	select {
+	case g.calls <- func() { fn(); close(finished) }:
+		<-finished
+		return true
+	case <-g.done:
+		return false
+	}
+}
+
+// getSummary returns the game's summary, or false if the game is gone.
+func (g *game) getSummary() (GameSummary, bool) {
+	var summary GameSummary
+	ok := g.do(func() { summary = g.summary() })
+	return summary, ok
+}
+
+// collect stops the game if it is collectable, and reports whether it did.
+func (g *game) collect() bool {
+	collected := true
+	g.do(func() {
+		collected = g.isCollectable()
+		g.stopped = collected
+		if collected && g.storage != nil {
+			if err := g.storage.Delete(g.id); err != nil {
+				log.Println("Failed to delete game", g.id, "from storage:", err)
+			}
+		}
+	})
+	return collected
+}
+
+func (g *game) handleJoin(c *client) error {
+	if c.playerID < 0 || c.playerID >= len(g.players) {
+		return errInvalidPlayerID
+	}
+	if g.players[c.playerID] != nil {
+		return errPlayerAlreadyConnected
+	}
+	g.players[c.playerID] = c
+	log.Println("Player", c.playerID, "connected to game", g.id)
+	if g.clock != nil && !g.clock.isStarted() && !g.gameState.IsGameEnded && len(g.openSlots()) == 0 {
+		// Everybody is here, so the clock starts; the opponent learns about it with the new state.
+		g.clock.start(g.gameState.TurnPlayerID, time.Now())
+		g.broadcastGameState()
+		return nil
+	}
+	g.sendGameState(c)
+	return nil
+}
+
+func (g *game) handleLeave(c *client) {
+	if g.players[c.playerID] == c {
+		g.players[c.playerID] = nil
+		log.Println("Player", c.playerID, "disconnected from game", g.id)
+	}
+	c.close()
+}
+
+func (g *game) handleMessage(msg clientMessage) {
+	if g.players[msg.client.playerID] != msg.client {
+		return // stale message from a connection that already left
+	}
+	if msg.rejection != nil {
+		msg.client.enqueue(*msg.rejection)
+		return
+	}
+	switch msg.messageType {
+	case MessageTypeAction:
+		if err := g.runAction(msg.action, true); err != nil {
+			log.Println("Failed to run action:", err)
+			msg.client.enqueue(NewMessageErrorFromRunAction(err, msg.action))
+		}
+	case MessageTypeGimmeGameState:
+		g.sendGameState(msg.client)
+	case MessageTypeTakeBackRequest:
+		g.handleTakeBackRequest(msg.client)
+	case MessageTypeTakeBackResponse:
+		g.handleTakeBackResponse(msg.client, msg.accepted)
+	}
+}
+
+// runAction runs an action on behalf of its player, and lets everybody know about the new state.
+// runAction runs the action, and lets its player take it back if isTakeBackable and take-backs are
+// enabled.
+func (g *game) runAction(action truco.Action, isTakeBackable bool) error {
+	var previous *truco.GameState
+	if g.rules.IsTakeBackEnabled && isTakeBackable {
+		previous = g.gameState.Clone()
+	}
+	if err := g.gameState.RunAction(action); err != nil {
+		return err
+	}
+	log.Println("Ran action on game", g.id, ":", action)
+	g.takeBack = nil
+	// Confirming the end of a round deals the next one, so it can't be taken back: players have seen the new cards.
+	if previous != nil && action.GetName() != truco.CONFIRM_ROUND_FINISHED {
+		g.takeBack = &takeBack{gameState: previous, playerID: action.GetPlayerID()}
+	}
+	g.restartClock(action.GetPlayerID())
+	g.save()
+	g.broadcastGameState()
+	// The action's events are only sent with the state right after it, so that they aren't
+	// replayed to clients that ask for the state later on.
+	g.gameState.Events = nil
+	return nil
+}
+
+// restartClock charges the elapsed time to the player whose clock was running, and starts the
+// clock of the player who must act next. movedPlayerID is the player who just acted, if any.
+func (g *game) restartClock(movedPlayerID int) {
+	if g.clock == nil || !g.clock.isStarted() {
+		return
+	}
+	now := time.Now()
+	g.clock.stop(now, movedPlayerID)
+	if !g.gameState.IsGameEnded {
+		g.clock.start(g.gameState.TurnPlayerID, now)
+	}
+}
+
+// handleTimeout runs the timeout action on behalf of the player who ran out of time.
+func (g *game) handleTimeout() {
+	playerID := g.clock.runningFor
+	g.clock.stop(time.Now(), -1)
+	action := g.timeoutAction(playerID)
+	log.Println("Player", playerID, "ran out of time on game", g.id)
+	// The server chose the action, so the player can't take it back to undo the time control.
+	if err := g.runAction(action, false); err != nil {
+		// Unreachable: the timeout action is always possible. Still, the game mustn't get stuck.
+		log.Println("Failed to run timeout action on game", g.id, ":", err)
+		if err := g.runAction(truco.NewActionForfeit(playerID), false); err != nil {
+			// Unreachable too, unless the game already ended. Otherwise, the clock is re-armed so
+			// that the game times out again rather than waiting forever.
+			log.Println("Failed to forfeit the game", g.id, "on timeout:", err)
+			if !g.gameState.IsGameEnded {
+				g.clock.start(g.gameState.TurnPlayerID, time.Now())
+				g.broadcastGameState()
+			}
+		}
+	}
+}
+
+func (g *game) timeoutAction(playerID int) truco.Action {
+	if g.rules.Clock.OnTimeout == TimeoutForfeit {
+		return truco.NewActionForfeit(playerID)
+	}
+	if g.gameState.IsRoundFinished {
+		return truco.NewActionConfirmRoundFinished(playerID)
+	}
+	if mazo := truco.NewActionSayMeVoyAlMazo(playerID); mazo.IsPossible(*g.gameState) {
+		return mazo
+	}
+	for _, action := range g.gameState.CalculatePossibleActions() {
+		if action.GetPlayerID() == playerID {
+			return action
+		}
+	}
+	return truco.NewActionForfeit(playerID)
+}
+
+func (g *game) handleTakeBackRequest(c *client) {
+	if g.takeBack == nil || g.takeBack.playerID != c.playerID || g.gameState.IsGameEnded {
+		c.enqueue(NewMessageError(ErrorCodeTakeBackNotAllowed, "there is no action of yours to take back", nil))
+		return
+	}
+	opponentIDs := g.opponentsOf(c.playerID)
+	for _, opponentID := range opponentIDs {
+		if g.players[opponentID] == nil {
+			// Somebody can't agree to it, so it's rejected straight away.
+			c.enqueue(NewMessageTakeBackResponse(opponentID, false))
+			return
+		}
+	}
+	g.takeBack.isRequested = true
+	g.takeBack.acceptedBy = map[int]bool{}
+	for _, opponentID := range opponentIDs {
+		g.players[opponentID].enqueue(NewMessageTakeBackRequest(c.playerID))
+	}
+}
+
+func (g *game) handleTakeBackResponse(c *client, accepted bool) {
+	if g.takeBack == nil || !g.takeBack.isRequested || g.gameState.TeamOf(g.takeBack.playerID) == g.gameState.TeamOf(c.playerID) || g.takeBack.acceptedBy[c.playerID] {
+		c.enqueue(NewMessageError(ErrorCodeTakeBackNotAllowed, "there is no take-back request to reply to", nil))
+		return
+	}
+	g.broadcast(NewMessageTakeBackResponse(c.playerID, accepted))
+	if !accepted {
+		g.takeBack.isRequested = false
+		return
+	}
+	g.takeBack.acceptedBy[c.playerID] = true
+	if len(g.takeBack.acceptedBy) < len(g.opponentsOf(g.takeBack.playerID)) {
+		return
+	}
+	log.Println("Player", g.takeBack.playerID, "took back their last action on game", g.id)
+	g.gameState = g.takeBack.gameState
+	g.takeBack = nil
+	g.restartClock(-1)
+	g.save()
+	g.broadcastGameState()
+}
+
+// opponentsOf returns the players of the other team.
+func (g *game) opponentsOf(playerID int) []int {
+	opponentIDs := []int{}
+	for id := range g.players {
+		if g.gameState.TeamOf(id) != g.gameState.TeamOf(playerID) {
+			opponentIDs = append(opponentIDs, id)
+		}
+	}
+	return opponentIDs
+}
+
+func (g *game) sendGameState(c *client) {
+	clientGameState := g.gameState.ToClientGameState(c.playerID)
+	clientGameState.Clock = g.clock.toClientClock(c.playerID, g.gameState.OpponentOf(c.playerID), time.Now())
+	msg, _ := NewMessageHeresGameState(clientGameState)
+	c.enqueue(msg)
+}
+
+func (g *game) broadcast(msg any) {
+	for _, c := range g.players {
+		if c != nil {
+			c.enqueue(msg)
+		}
+	}
+}
+
+func (g *game) broadcastGameState() {
+	for _, c := range g.players {
+		if c != nil {
+			g.sendGameState(c)
+		}
+	}
+}
+
+func (g *game) openSlots() []int {
+	slots := []int{}
+	for playerID, c := range g.players {
+		if c == nil {
+			slots = append(slots, playerID)
+		}
+	}
+	return slots
+}
+
+func (g *game) connectedCount() int {
+	return len(g.players) - len(g.openSlots())
+}
+
+// isCollectable is true if the game is over and nobody is looking at it anymore.
+func (g *game) isCollectable() bool {
+	return g.gameState.IsGameEnded && g.connectedCount() == 0
+}
+
+func (g *game) summary() GameSummary {
+	return GameSummary{
+		ID:          g.id,
+		Rules:       g.rules,
+		OpenSlots:   g.openSlots(),
+		IsGameEnded: g.gameState.IsGameEnded,
+		CreatedAt:   g.createdAt,
+	}
+}
+
+// outboxSize is how many messages may be pending for a client before it's considered too slow.
+const outboxSize = 16
+
+// client is a player's connection to a game. The game's goroutine writes to it through its
+// outbox, which is drained by the connection's own writer goroutine, so that a slow client
+// never blocks the game.
+type client struct {
+	playerID int
+	conn     *websocket.Conn
+	outbox   chan any
+	closed   bool // only accessed by the game's goroutine
+}
+
+func newClient(playerID int, conn *websocket.Conn) *client {
+	return &client{playerID: playerID, conn: conn, outbox: make(chan any, outboxSize)}
+}
+
+func (c *client) enqueue(msg any) {
+	if c.closed {
+		return
+	}
+	select {
+	case c.outbox <- msg:
+	default:
+		log.Println("Player", c.playerID, "is too slow, dropping connection")
+		c.close()
+	}
+}
+
+func (c *client) close() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.outbox)
+}
+
+// writeLoop sends the messages in the outbox until it's closed. Then, it closes the
+// connection, which also ends the connection's read loop.
+func (c *client) writeLoop() {
+	defer c.conn.Close()
+	for msg := range c.outbox {
+		if err := WsSend(c.conn, msg); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// DefaultGameID is the game that clients join when their hello message doesn't specify a game.
+// It is created on demand, so that a plain `truco server` keeps working for a single match.
+const DefaultGameID = "default"
+
+var validGameID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var (
+	errInvalidGameID     = errors.New("invalid game ID")
+	errGameAlreadyExists = errors.New("game already exists")
+	errGameNotFound      = errors.New("game not found")
+)
+
+// CreateGameRequest is the body of a POST /games request. ID is optional; a random one is
+// generated if empty.
+type CreateGameRequest struct {
+	ID    string    `json:"id"`
+	Rules GameRules `json:"rules"`
+}
+
+// GameSummary is how a game is listed by GET /games and returned by POST /games.
+type GameSummary struct {
+	ID          string    `json:"id"`
+	Rules       GameRules `json:"rules"`
+	OpenSlots   []int     `json:"openSlots"`
+	IsGameEnded bool      `json:"isGameEnded"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (s *server) createGame(id string, rules GameRules) (*game, error) {
+	if id == "" {
+		id = randomGameID()
+	}
+	if !validGameID.MatchString(id) {
+		return nil, errInvalidGameID
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.games[id]; ok {
+		return nil, errGameAlreadyExists
+	}
+	g := newGame(id, rules, s.storage)
+	s.games[id] = g
+	return g, nil
+}
+
+// findGame returns the game with the given ID. The default game is created if it doesn't exist.
+func (s *server) findGame(id string) (*game, error) {
+	if id == "" {
+		id = DefaultGameID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok && id == DefaultGameID {
+		g = newGame(id, GameRules{}, s.storage)
+		s.games[id] = g
+		ok = true
+	}
+	if !ok {
+		return nil, errGameNotFound
+	}
+	return g, nil
+}
+
+// openGames returns the games that haven't ended and have at least one free slot, oldest first.
+func (s *server) openGames() []GameSummary {
+	summaries := []GameSummary{}
+	for _, g := range s.allGames() {
+		summary, ok := g.getSummary()
+		if !ok || summary.IsGameEnded || len(summary.OpenSlots) == 0 {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.Before(summaries[j].CreatedAt)
+	})
+	return summaries
+}
+
+func (s *server) allGames() []*game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	games := make([]*game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	return games
+}
+
+// collectFinishedGames stops and removes ended games that no player is connected to anymore.
+func (s *server) collectFinishedGames() {
+	for _, g := range s.allGames() {
+		if !g.collect() {
+			continue
+		}
+		log.Println("Collecting finished game", g.id)
+		s.mu.Lock()
+		if s.games[g.id] == g {
+			delete(s.games, g.id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// restoreGames resumes all games in storage. It's meant to be called on startup, before
+// accepting connections.
+func (s *server) restoreGames() error {
+	if s.storage == nil {
+		return nil
+	}
+	storedGames, err := s.storage.LoadAll()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range storedGames {
+		g, err := restoreGame(stored, s.storage)
+		if err != nil {
+			return err
+		}
+		s.games[g.id] = g
+	}
+	log.Printf("Restored %v games from storage\n", len(storedGames))
+	return nil
+}
+
+func (s *server) startGameCollector(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			s.collectFinishedGames()
+		}
+	}()
+}
+
+func (s *server) handleCreateGame(w http.ResponseWriter, r *http.Request) {
+	var req CreateGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	g, err := s.createGame(req.ID, req.Rules)
+	switch {
+	case errors.Is(err, errGameAlreadyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	summary, _ := g.getSummary()
+	writeJSON(w, http.StatusCreated, summary)
+}
+
+func (s *server) handleListGames(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.openGames())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+func randomGameID() string {
+	bs := make([]byte, 6)
+	_, _ = rand.Read(bs)
+	return hex.EncodeToString(bs)
+}
@@ -0,0 +1,117 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndListGames(t *testing.T) {
+	s := New("0")
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/games", "application/json", strings.NewReader(`{"id": "office", "rules": {"maxPoints": 15, "isFlorEnabled": true}}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(ts.URL+"/games", "application/json", strings.NewReader(`{"id": "office"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(ts.URL+"/games", "application/json", strings.NewReader(`{"id": "not a valid id!"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/games")
+	require.NoError(t, err)
+	var summaries []GameSummary
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&summaries))
+	resp.Body.Close()
+
+	require.Len(t, summaries, 1)
+	require.Equal(t, "office", summaries[0].ID)
+	require.Equal(t, GameRules{MaxPoints: 15, IsFlorEnabled: true}, summaries[0].Rules)
+	require.Equal(t, []int{0, 1}, summaries[0].OpenSlots)
+
+	g, err := s.findGame("office")
+	require.NoError(t, err)
+	g.do(func() {
+		require.Equal(t, 15, g.gameState.Rules.MaxPoints)
+		require.True(t, g.gameState.Rules.IsFlorEnabled)
+	})
+}
+
+func TestFindGame(t *testing.T) {
+	s := New("0")
+
+	_, err := s.findGame("missing")
+	require.ErrorIs(t, err, errGameNotFound)
+
+	g, err := s.findGame("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultGameID, g.id)
+
+	again, err := s.findGame(DefaultGameID)
+	require.NoError(t, err)
+	require.Same(t, g, again)
+}
+
+func TestCollectFinishedGames(t *testing.T) {
+	s := New("0")
+	finished, err := s.createGame("finished", GameRules{})
+	require.NoError(t, err)
+	_, err = s.createGame("ongoing", GameRules{})
+	require.NoError(t, err)
+	watched, err := s.createGame("watched", GameRules{})
+	require.NoError(t, err)
+
+	finished.do(func() { finished.gameState.IsGameEnded = true })
+	watched.do(func() { watched.gameState.IsGameEnded = true })
+	require.NoError(t, watched.join(newClient(0, &websocket.Conn{})))
+
+	s.collectFinishedGames()
+
+	_, err = s.findGame("finished")
+	require.ErrorIs(t, err, errGameNotFound)
+	<-finished.done
+	_, err = s.findGame("ongoing")
+	require.NoError(t, err)
+	_, err = s.findGame("watched")
+	require.NoError(t, err)
+}
+
+func TestJoinSpecificGame(t *testing.T) {
+	s := New("0")
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	_, err := s.createGame("office", GameRules{MaxPoints: 15})
+	require.NoError(t, err)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, WsSend(conn, NewMessageHello("office", 1)))
+	clientGameState, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, 1, clientGameState.YouPlayerID)
+	require.Equal(t, 15, clientGameState.RuleMaxPoints)
+
+	summaries := s.openGames()
+	require.Len(t, summaries, 1)
+	require.Equal(t, []int{0}, summaries[0].OpenSlots)
+}
@@ -0,0 +1,105 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage persists games, so that they survive a server restart.
+//
+// Games are saved when they are created and after every action, and deleted when collected.
+// Implementations must be safe for concurrent use, since each game saves from its own goroutine.
+type Storage interface {
+	Save(game StoredGame) error
+	LoadAll() ([]StoredGame, error)
+	Delete(id string) error
+}
+
+// StoredGame is a snapshot of a game, as persisted by a Storage.
+type StoredGame struct {
+	ID        string    `json:"id"`
+	Rules     GameRules `json:"rules"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// GameState is the output of truco.GameState.Serialize.
+	GameState json.RawMessage `json:"gameState"`
+}
+
+// FileStorage stores each game as a JSON file in a directory.
+type FileStorage struct {
+	dir string
+}
+
+// NewFileStorage returns a FileStorage on the given directory, creating it if needed.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating storage directory: %w", err)
+	}
+	return &FileStorage{dir: dir}, nil
+}
+
+const storedGameExtension = ".json"
+
+func (fs *FileStorage) path(id string) string {
+	return filepath.Join(fs.dir, id+storedGameExtension)
+}
+
+// Save writes the game to a temporary file and then renames it, so that a crash mid-write
+// never leaves a corrupt snapshot behind.
+func (fs *FileStorage) Save(game StoredGame) error {
+	bs, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(fs.dir, game.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fs.path(game.ID))
+}
+
+func (fs *FileStorage) LoadAll() ([]StoredGame, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+	games := []StoredGame{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), storedGameExtension) {
+			continue
+		}
+		bs, err := os.ReadFile(filepath.Join(fs.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var game StoredGame
+		if err := json.Unmarshal(bs, &game); err != nil {
+			return nil, fmt.Errorf("loading %v: %w", entry.Name(), err)
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+func (fs *FileStorage) Delete(id string) error {
+	if err := os.Remove(fs.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFileStorage(dir)
+	require.NoError(t, err)
+
+	games, err := storage.LoadAll()
+	require.NoError(t, err)
+	require.Empty(t, games)
+
+	a := StoredGame{ID: "a", Rules: GameRules{MaxPoints: 15}, GameState: []byte(`{"roundNumber":1}`)}
+	b := StoredGame{ID: "b", GameState: []byte(`{"roundNumber":2}`)}
+	require.NoError(t, storage.Save(a))
+	require.NoError(t, storage.Save(b))
+	a.GameState = []byte(`{"roundNumber":3}`)
+	require.NoError(t, storage.Save(a))
+
+	games, err = storage.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, games, 2)
+	require.Equal(t, "a", games[0].ID)
+	require.Equal(t, GameRules{MaxPoints: 15}, games[0].Rules)
+	require.JSONEq(t, `{"roundNumber":3}`, string(games[0].GameState))
+
+	require.NoError(t, storage.Delete("b"))
+	require.NoError(t, storage.Delete("b"))
+	games, err = storage.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "a.json", filepath.Base(entries[0].Name()))
+}
+
+func TestGamesAreResumedAfterRestart(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+
+	// Play a few actions on a server.
+	_, url := startTestServer(t, WithStorage(storage))
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		action, err := truco.DeserializeAction(state.PossibleActions[0])
+		require.NoError(t, err)
+		msg, _ := NewMessageAction(action)
+		require.NoError(t, WsSend(p0, msg))
+		state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+		require.NoError(t, err)
+		if state.TurnPlayerID != 0 {
+			break
+		}
+	}
+	p0.Close()
+
+	// A new server on the same storage resumes the game where it was left.
+	s, url := startTestServer(t, WithStorage(storage))
+	require.NoError(t, s.restoreGames())
+	p0 = dialPlayer(t, url, DefaultGameID, 0)
+	resumedState, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, state, resumedState)
+
+	// And it can keep being played.
+	p1 := dialPlayer(t, url, DefaultGameID, 1)
+	state1, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.NotEmpty(t, state1.PossibleActions)
+	action, err := truco.DeserializeAction(state1.PossibleActions[0])
+	require.NoError(t, err)
+	msg, _ := NewMessageAction(action)
+	require.NoError(t, WsSend(p1, msg))
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+}
+
+func TestCollectedGamesAreDeletedFromStorage(t *testing.T) {
+	storage, err := NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+	s := New("0", WithStorage(storage))
+	g, err := s.createGame("finished", GameRules{})
+	require.NoError(t, err)
+
+	games, err := storage.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+
+	g.do(func() { g.gameState.IsGameEnded = true })
+	s.collectFinishedGames()
+
+	games, err = storage.LoadAll()
+	require.NoError(t, err)
+	require.Empty(t, games)
+}
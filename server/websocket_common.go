@@ -33,6 +33,23 @@ func WsReadMessage[U any, T IWebsocketMessage[U]](conn *websocket.Conn, expected
 	return WsDeserializeMessage[U, T](message, expectedType)
 }
 
+// WsReadAnyMessage reads the next message of any type. Use the returned type to pick how to
+// deserialize it with WsDeserializeMessage.
+func WsReadAnyMessage(conn *websocket.Conn) (int, []byte, error) {
+	messageType, message, err := conn.ReadMessage()
+	if err != nil {
+		return 0, nil, fmt.Errorf("Failed to read message: %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		return 0, nil, fmt.Errorf("Expected text message, got %d", messageType)
+	}
+	var m WebsocketMessage
+	if err := json.Unmarshal(message, &m); err != nil {
+		return 0, nil, fmt.Errorf("Failed to unmarshal message: %v", err)
+	}
+	return m.Type, message, nil
+}
+
 func WsDeserializeMessage[U any, T IWebsocketMessage[U]](message []byte, expectedType int) (*U, error) {
 	var m T
 	if err := json.Unmarshal(message, &m); err != nil {
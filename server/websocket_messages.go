@@ -5,6 +5,8 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/marianogappa/truco/truco"
 )
@@ -14,6 +16,25 @@ const (
 	MessageTypeHeresGameState
 	MessageTypeAction
 	MessageTypeGimmeGameState
+	MessageTypeError
+	MessageTypeTakeBackRequest
+	MessageTypeTakeBackResponse
+)
+
+// Error codes sent in MessageError, so that clients can tell why their message was rejected.
+const (
+	ErrorCodeNotYourTurn        = "not_your_turn"
+	ErrorCodeActionNotPossible  = "action_not_possible"
+	ErrorCodeGameIsEnded        = "game_is_ended"
+	ErrorCodeUnknown            = "unknown"
+	ErrorCodeWrongPlayer        = "wrong_player"
+	ErrorCodeInvalidMessage     = "invalid_message"
+	ErrorCodeTakeBackNotAllowed = "take_back_not_allowed"
+
+	// Errors sent when a client can't join a game, right before the server closes the connection.
+	ErrorCodeGameNotFound  = "game_not_found"
+	ErrorCodeInvalidPlayer = "invalid_player"
+	ErrorCodeSlotTaken     = "slot_taken"
 )
 
 type IWebsocketMessage[T any] interface {
@@ -31,15 +52,18 @@ func (m WebsocketMessage) GetType() int {
 
 type MessageHello struct {
 	WebsocketMessage
-	PlayerID int `json:"playerID"`
+
+	// GameID is the ID of the game to join. If empty, the server's default game is joined.
+	GameID   string `json:"gameID"`
+	PlayerID int    `json:"playerID"`
 }
 
-func NewMessageHello(playerID int) MessageHello {
-	return MessageHello{WebsocketMessage: WebsocketMessage{Type: MessageTypeHello}, PlayerID: playerID}
+func NewMessageHello(gameID string, playerID int) MessageHello {
+	return MessageHello{WebsocketMessage: WebsocketMessage{Type: MessageTypeHello}, GameID: gameID, PlayerID: playerID}
 }
 
-func (m MessageHello) Deserialize() (int, error) {
-	return m.PlayerID, nil
+func (m MessageHello) Deserialize() (MessageHello, error) {
+	return m, nil
 }
 
 type MessageHeresGameState struct {
@@ -79,3 +103,110 @@ func NewMessageAction(action truco.Action) (MessageAction, error) {
 func (a MessageAction) Deserialize() (truco.Action, error) {
 	return truco.DeserializeAction(a.Action)
 }
+
+// MessageError is sent to a client when the server rejects one of its messages, e.g. when
+// the action it sent can't be run. Clients keep their last game state, which is still current.
+type MessageError struct {
+	WebsocketMessage
+
+	// Code is a machine-readable error code; one of the ErrorCode... constants.
+	Code string `json:"code"`
+
+	// Message is a human-readable description of the error, for logging purposes.
+	Message string `json:"message"`
+
+	// Action is the action that was rejected, if the rejected message was an action.
+	Action json.RawMessage `json:"action,omitempty"`
+}
+
+func NewMessageError(code string, message string, action truco.Action) MessageError {
+	msg := MessageError{WebsocketMessage: WebsocketMessage{Type: MessageTypeError}, Code: code, Message: message}
+	if action != nil {
+		msg.Action = truco.SerializeAction(action)
+	}
+	return msg
+}
+
+// NewMessageErrorFromRunAction builds the error reply for an action that GameState.RunAction rejected.
+func NewMessageErrorFromRunAction(err error, action truco.Action) MessageError {
+	return NewMessageError(runActionErrorCode(err), err.Error(), action)
+}
+
+func runActionErrorCode(err error) string {
+	switch {
+	case errors.Is(err, truco.ErrNotYourTurn):
+		return ErrorCodeNotYourTurn
+	case errors.Is(err, truco.ErrGameIsEnded):
+		return ErrorCodeGameIsEnded
+	case errors.Is(err, truco.ErrActionNotPossible), errors.Is(err, truco.ErrEnvidoFinished):
+		return ErrorCodeActionNotPossible
+	default:
+		return ErrorCodeUnknown
+	}
+}
+
+// NewMessageErrorFromJoin builds the error reply for a client that couldn't join a game.
+func NewMessageErrorFromJoin(err error) MessageError {
+	return NewMessageError(joinErrorCode(err), err.Error(), nil)
+}
+
+func joinErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errGameNotFound), errors.Is(err, errInvalidGameID):
+		return ErrorCodeGameNotFound
+	case errors.Is(err, errInvalidPlayerID):
+		return ErrorCodeInvalidPlayer
+	case errors.Is(err, errPlayerAlreadyConnected):
+		return ErrorCodeSlotTaken
+	default:
+		return ErrorCodeUnknown
+	}
+}
+
+func (m MessageError) Deserialize() (MessageError, error) {
+	return m, nil
+}
+
+func (m MessageError) Error() string {
+	return fmt.Sprintf("%v: %v", m.Code, m.Message)
+}
+
+// MessageTakeBackRequest asks to take back the last action, in games with take-backs enabled.
+//
+// A client sends it to take back its own last action. The server forwards it to every player of
+// the opposing team, who must each reply with a MessageTakeBackResponse. The action is only taken
+// back if all of them accept it, and a single rejection rejects it.
+type MessageTakeBackRequest struct {
+	WebsocketMessage
+
+	// PlayerID is the player who asks to take back their last action. It is set by the server.
+	PlayerID int `json:"playerID"`
+}
+
+func NewMessageTakeBackRequest(playerID int) MessageTakeBackRequest {
+	return MessageTakeBackRequest{WebsocketMessage: WebsocketMessage{Type: MessageTypeTakeBackRequest}, PlayerID: playerID}
+}
+
+func (m MessageTakeBackRequest) Deserialize() (MessageTakeBackRequest, error) {
+	return m, nil
+}
+
+// MessageTakeBackResponse accepts or rejects an opponent's MessageTakeBackRequest.
+//
+// The server sends it to every player each time an opponent replies. Once all the opponents
+// accepted, the game state from before the taken back action follows.
+type MessageTakeBackResponse struct {
+	WebsocketMessage
+
+	// PlayerID is the player who replied to the request. It is set by the server.
+	PlayerID int  `json:"playerID"`
+	Accepted bool `json:"accepted"`
+}
+
+func NewMessageTakeBackResponse(playerID int, accepted bool) MessageTakeBackResponse {
+	return MessageTakeBackResponse{WebsocketMessage: WebsocketMessage{Type: MessageTypeTakeBackResponse}, PlayerID: playerID, Accepted: accepted}
+}
+
+func (m MessageTakeBackResponse) Deserialize() (MessageTakeBackResponse, error) {
+	return m, nil
+}
@@ -5,8 +5,11 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -19,22 +22,59 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// TODO: resources shouldn't be shared between goroutines! It's not panicking due to insufficient testing for now.
+// gameCollectionInterval is how often finished games are garbage-collected.
+const gameCollectionInterval = time.Minute
+
+// defaultMaxViolations is how many invalid messages a connection may send before it's dropped.
+const defaultMaxViolations = 5
+
 type server struct {
-	gameState *truco.GameState
-	port      string
-	players   []*websocket.Conn
+	port          string
+	maxViolations int
+	storage       Storage
+
+	mu    sync.Mutex
+	games map[string]*game
 }
 
-func New(port string) *server {
-	return &server{gameState: truco.New(), port: port, players: []*websocket.Conn{nil, nil}}
+func New(port string, opts ...func(*server)) *server {
+	s := &server{port: port, maxViolations: defaultMaxViolations, games: map[string]*game{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithMaxViolations sets how many invalid messages (e.g. actions on behalf of another player)
+// a connection may send before it's dropped. Each of them is rejected with an error message.
+func WithMaxViolations(maxViolations int) func(*server) {
+	return func(s *server) {
+		s.maxViolations = maxViolations
+	}
+}
+
+// WithStorage persists games to the given storage, and resumes them when the server starts.
+func WithStorage(storage Storage) func(*server) {
+	return func(s *server) {
+		s.storage = storage
+	}
 }
 
 func (s *server) Start() {
+	if err := s.restoreGames(); err != nil {
+		log.Fatal(err)
+	}
+	s.startGameCollector(gameCollectionInterval)
+	log.Printf("Server running on port %v\n", s.port)
+	log.Fatal(http.ListenAndServe(":"+s.port, s.router()))
+}
+
+func (s *server) router() *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/ws", s.handleWebSocket)
-	log.Printf("Server running on port %v\n", s.port)
-	log.Fatal(http.ListenAndServe(":"+s.port, router))
+	router.HandleFunc("/games", s.handleCreateGame).Methods(http.MethodPost)
+	router.HandleFunc("/games", s.handleListGames).Methods(http.MethodGet)
+	return router
 }
 
 func (s *server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
@@ -43,82 +83,108 @@ func (s *server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		log.Println("Failed to upgrade connection to WebSocket:", err)
 		return
 	}
-	defer conn.Close()
 
-	playerID, err := WsReadMessage[int, MessageHello](conn, MessageTypeHello)
+	hello, err := WsReadMessage[MessageHello, MessageHello](conn, MessageTypeHello)
 	if err != nil {
 		log.Println(err)
+		rejectConnection(conn, NewMessageError(ErrorCodeInvalidMessage, fmt.Sprintf("expected a hello message: %v", err), nil))
 		return
 	}
+	playerID := hello.PlayerID
 
-	if *playerID < 0 || *playerID > 1 {
-		log.Println("Invalid player ID")
-		return
-	}
-	if s.players[*playerID] != nil {
-		log.Println("Player already connected")
+	g, err := s.findGame(hello.GameID)
+	if err != nil {
+		log.Println(err, hello.GameID)
+		rejectConnection(conn, NewMessageErrorFromJoin(err))
 		return
 	}
-	s.players[*playerID] = conn
-
-	msg, _ := NewMessageHeresGameState(s.gameState.ToClientGameState(*playerID))
-	if err := WsSend(conn, msg); err != nil {
+	c := newClient(playerID, conn)
+	if err := g.join(c); err != nil {
 		log.Println(err)
+		rejectConnection(conn, NewMessageErrorFromJoin(err))
 		return
 	}
-	log.Println("Player", *playerID, "connected")
+	// From now on, the writer goroutine owns closing the connection, which happens after leaving.
+	go c.writeLoop()
+	defer g.leave(c)
 
+	// Invalid messages are rejected with an error message, and too many of them drop the connection.
+	violations := 0
 	for {
-		log.Println("Waiting for action/state_request from player", *playerID)
+		log.Println("Waiting for action/state_request from player", playerID, "on game", g.id)
 		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Println("Failed to read message from client, freeing slot:", err)
-			s.players[*playerID] = nil
-			break
+			return
 		}
 
-		var wsMessage WebsocketMessage
-		if err := json.Unmarshal(message, &wsMessage); err != nil {
-			log.Println("Failed to unmarshal message:", err)
-			break
+		msg, rejection := parseClientMessage(c, message)
+		if rejection != nil {
+			violations++
+			log.Printf("Rejected message from player %v on game %v (violation %v of %v): %v", playerID, g.id, violations, s.maxViolations, rejection)
+			msg = clientMessage{client: c, rejection: rejection}
+		}
+		if !g.send(msg) {
+			return
+		}
+		if violations >= s.maxViolations {
+			log.Println("Dropping player", playerID, "on game", g.id, "after too many violations")
+			return
 		}
+	}
+}
+
+// rejectConnection tells the client why it can't join a game, and closes the connection.
+func rejectConnection(conn *websocket.Conn, msgErr MessageError) {
+	if err := WsSend(conn, msgErr); err != nil {
+		log.Println("Failed to send error to rejected client:", err)
+	}
+	conn.Close()
+}
+
+// parseClientMessage deserializes a message received from a client. If the message is invalid,
+// it returns the error to reply to the client with.
+func parseClientMessage(c *client, message []byte) (clientMessage, *MessageError) {
+	var wsMessage WebsocketMessage
+	if err := json.Unmarshal(message, &wsMessage); err != nil {
+		msgErr := NewMessageError(ErrorCodeInvalidMessage, fmt.Sprintf("failed to unmarshal message: %v", err), nil)
+		return clientMessage{}, &msgErr
+	}
 
-		switch wsMessage.Type {
-		case MessageTypeAction:
-			log.Println("Got action message:", string(message))
-			action, err := WsDeserializeMessage[truco.Action, MessageAction](message, MessageTypeAction)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			if (*action).GetPlayerID() != *playerID {
-				log.Fatal("Player", *playerID, " tried to run action for player", (*action).GetPlayerID())
-			}
-			err = s.gameState.RunAction(*action)
-			if err != nil {
-				// TODO write back to the connection
-				log.Println("Failed to run action:", err)
-				break
-			}
-
-			log.Println("Ran action message:", string(message))
-
-			for i, playerConn := range s.players {
-				log.Println("Sending game state to player", i)
-				msg, _ := NewMessageHeresGameState(s.gameState.ToClientGameState(i))
-				if err := WsSend(playerConn, msg); err != nil {
-					log.Println(err)
-					return
-				}
-			}
-		case MessageTypeGimmeGameState:
-			log.Println("Got state request message:", string(message))
-
-			msg, _ := NewMessageHeresGameState(s.gameState.ToClientGameState(*playerID))
-			if err := WsSend(conn, msg); err != nil {
-				log.Println(err)
-				return
-			}
+	msg := clientMessage{client: c, messageType: wsMessage.Type}
+	switch wsMessage.Type {
+	case MessageTypeAction:
+		log.Println("Got action message:", string(message))
+		action, err := WsDeserializeMessage[truco.Action, MessageAction](message, MessageTypeAction)
+		if err != nil {
+			msgErr := NewMessageError(ErrorCodeInvalidMessage, err.Error(), nil)
+			return clientMessage{}, &msgErr
+		}
+		if (*action).GetPlayerID() != c.playerID {
+			msgErr := NewMessageError(ErrorCodeWrongPlayer, fmt.Sprintf("player %v tried to run action for player %v", c.playerID, (*action).GetPlayerID()), *action)
+			return clientMessage{}, &msgErr
+		}
+		// Forfeits are only run by the server, e.g. on timeouts; otherwise any player could end the game.
+		if (*action).GetName() == truco.FORFEIT {
+			msgErr := NewMessageError(ErrorCodeActionNotPossible, "players can't forfeit the game", *action)
+			return clientMessage{}, &msgErr
+		}
+		msg.action = *action
+	case MessageTypeGimmeGameState:
+		log.Println("Got state request message:", string(message))
+	case MessageTypeTakeBackRequest:
+		log.Println("Got take-back request message:", string(message))
+	case MessageTypeTakeBackResponse:
+		log.Println("Got take-back response message:", string(message))
+		response, err := WsDeserializeMessage[MessageTakeBackResponse, MessageTakeBackResponse](message, MessageTypeTakeBackResponse)
+		if err != nil {
+			msgErr := NewMessageError(ErrorCodeInvalidMessage, err.Error(), nil)
+			return clientMessage{}, &msgErr
 		}
+		msg.accepted = response.Accepted
+	default:
+		msgErr := NewMessageError(ErrorCodeInvalidMessage, fmt.Sprintf("unexpected message type %v", wsMessage.Type), nil)
+		return clientMessage{}, &msgErr
 	}
+	return msg, nil
 }
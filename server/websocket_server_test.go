@@ -0,0 +1,603 @@
+//go:build !tinygo
+// +build !tinygo
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func startTestServer(t *testing.T, opts ...func(*server)) (*server, string) {
+	s := New("0", opts...)
+	ts := httptest.NewServer(s.router())
+	t.Cleanup(ts.Close)
+	return s, "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+}
+
+func dialPlayer(t *testing.T, url, gameID string, playerID int) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, WsSend(conn, NewMessageHello(gameID, playerID)))
+	return conn
+}
+
+// playUntilGameEnds plays the first possible action upon every game state received. Both players
+// do this at the same time, so many of the actions are sent based on stale states, and race each other.
+// Meanwhile, if isAskingForState, the player without actions keeps asking for the game state, to have
+// even more concurrent messages. Stale actions are rejected by the server with an error message, which is ignored.
+//
+// Team games have so many players without actions that, if they all keep asking, the server may find
+// some of them too slow and drop them. So, they should only wait for the game state instead.
+func playUntilGameEnds(conn *websocket.Conn, isAskingForState bool) error {
+	var (
+		writes = make(chan any, 64)
+		done   = make(chan struct{})
+		wg     sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case msg := <-writes:
+				if err := WsSend(conn, msg); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer wg.Wait()
+	defer close(done)
+
+	for {
+		messageType, message, err := WsReadAnyMessage(conn)
+		if err != nil {
+			return err
+		}
+		if messageType == MessageTypeError {
+			continue
+		}
+		clientGameState, err := WsDeserializeMessage[truco.ClientGameState, MessageHeresGameState](message, messageType)
+		if err != nil {
+			return err
+		}
+		if clientGameState.IsGameEnded {
+			return nil
+		}
+		if len(clientGameState.PossibleActions) == 0 {
+			if !isAskingForState {
+				continue
+			}
+			select {
+			case writes <- NewMessageGimmeGameState():
+			default:
+			}
+			continue
+		}
+		action, err := truco.DeserializeAction(clientGameState.PossibleActions[0])
+		if err != nil {
+			return err
+		}
+		msg, _ := NewMessageAction(action)
+		select {
+		case writes <- msg:
+		default:
+		}
+	}
+}
+
+func TestConcurrentClientsPlayManyGamesToTheEnd(t *testing.T) {
+	s, url := startTestServer(t)
+
+	const numGames = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, numGames*2)
+	for i := 0; i < numGames; i++ {
+		gameID := fmt.Sprintf("game-%d", i)
+		_, err := s.createGame(gameID, GameRules{MaxPoints: 15, IsFlorEnabled: i%2 == 0})
+		require.NoError(t, err)
+		for playerID := 0; playerID < 2; playerID++ {
+			conn := dialPlayer(t, url, gameID, playerID)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- playUntilGameEnds(conn, true)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < numGames; i++ {
+		g, err := s.findGame(fmt.Sprintf("game-%d", i))
+		require.NoError(t, err)
+		g.do(func() {
+			require.True(t, g.gameState.IsGameEnded)
+			require.Equal(t, 15, g.gameState.Players[g.gameState.WinnerPlayerID].Score)
+		})
+	}
+}
+
+func TestTeamGamePlaysToTheEnd(t *testing.T) {
+	for _, rules := range []GameRules{
+		{MaxPoints: 15, PlayerCount: 4},
+		{MaxPoints: 15, PlayerCount: 6, PicaPicaFrom: 3, PicaPicaTo: 12},
+	} {
+		testTeamGamePlaysToTheEnd(t, rules)
+	}
+}
+
+func testTeamGamePlaysToTheEnd(t *testing.T, rules GameRules) {
+	s, url := startTestServer(t)
+	g, err := s.createGame("teams", rules)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, rules.PlayerCount)
+	for playerID := 0; playerID < rules.PlayerCount; playerID++ {
+		conn := dialPlayer(t, url, "teams", playerID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- playUntilGameEnds(conn, false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	g.do(func() {
+		require.True(t, g.gameState.IsGameEnded)
+		winnerTeamID := g.gameState.TeamOf(g.gameState.WinnerPlayerID)
+		for playerID, player := range g.gameState.Players {
+			require.Equal(t, g.gameState.TeamOf(playerID) == winnerTeamID, player.Score == 15)
+		}
+	})
+}
+
+func TestInvalidPlayerCount(t *testing.T) {
+	s := New("0")
+	_, err := s.createGame("", GameRules{PlayerCount: 3})
+	require.ErrorIs(t, err, errInvalidPlayerCount)
+	_, err = s.createGame("", GameRules{PlayerCount: 4, PicaPicaFrom: 5, PicaPicaTo: 25})
+	require.ErrorIs(t, err, errInvalidPicaPica)
+	_, err = s.createGame("", GameRules{PlayerCount: 6, PicaPicaFrom: 25, PicaPicaTo: 5})
+	require.ErrorIs(t, err, errInvalidPicaPica)
+}
+
+func TestInvalidHouseRules(t *testing.T) {
+	s := New("0")
+	_, err := s.createGame("", GameRules{IsContraflorDisabled: true})
+	require.ErrorIs(t, err, truco.ErrInvalidRules)
+	_, err = s.createGame("", GameRules{FaltaEnvido: "half"})
+	require.ErrorIs(t, err, truco.ErrInvalidRules)
+
+	g, err := s.createGame("", GameRules{IsFlorEnabled: true, IsContraflorDisabled: true, FaltaEnvido: truco.FALTA_ENVIDO_POINTS_TO_WIN})
+	require.NoError(t, err)
+	require.True(t, g.gameState.Rules.IsContraflorDisabled)
+	require.Equal(t, truco.FALTA_ENVIDO_POINTS_TO_WIN, g.gameState.Rules.FaltaEnvido)
+}
+
+func TestConcurrentJoinsTakeEachSlotOnce(t *testing.T) {
+	s, url := startTestServer(t)
+
+	const numClients = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted = map[int]int{}
+	)
+	for i := 0; i < numClients; i++ {
+		playerID := i % 2
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn := dialPlayer(t, url, DefaultGameID, playerID)
+			// Only accepted players get a game state; the others get disconnected.
+			if _, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState); err != nil {
+				return
+			}
+			mu.Lock()
+			accepted[playerID]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, map[int]int{0: 1, 1: 1}, accepted)
+	g, err := s.findGame(DefaultGameID)
+	require.NoError(t, err)
+	summary, ok := g.getSummary()
+	require.True(t, ok)
+	require.Empty(t, summary.OpenSlots)
+}
+
+func TestSlotIsFreedOnDisconnect(t *testing.T) {
+	s, url := startTestServer(t)
+
+	for i := 0; i < 10; i++ {
+		conn := dialPlayer(t, url, DefaultGameID, 0)
+		_, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState)
+		require.NoError(t, err)
+		conn.Close()
+
+		g, err := s.findGame(DefaultGameID)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			summary, _ := g.getSummary()
+			return len(summary.OpenSlots) == 2
+		}, time.Second, time.Millisecond)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	_, url := startTestServer(t)
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	_, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		gameID       string
+		playerID     int
+		expectedCode string
+	}{
+		{name: "unknown game", gameID: "nope", playerID: 0, expectedCode: ErrorCodeGameNotFound},
+		{name: "player ID beyond the game's players", gameID: DefaultGameID, playerID: 2, expectedCode: ErrorCodeInvalidPlayer},
+		{name: "negative player ID", gameID: DefaultGameID, playerID: -1, expectedCode: ErrorCodeInvalidPlayer},
+		{name: "slot taken", gameID: DefaultGameID, playerID: 0, expectedCode: ErrorCodeSlotTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialPlayer(t, url, tt.gameID, tt.playerID)
+			msgErr, err := WsReadMessage[MessageError, MessageError](conn, MessageTypeError)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCode, msgErr.Code)
+
+			// Then, the connection is closed.
+			_, _, err = conn.ReadMessage()
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("no hello", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.NoError(t, WsSend(conn, NewMessageGimmeGameState()))
+		msgErr, err := WsReadMessage[MessageError, MessageError](conn, MessageTypeError)
+		require.NoError(t, err)
+		require.Equal(t, ErrorCodeInvalidMessage, msgErr.Code)
+	})
+}
+
+func TestMessagesFromAnotherGameDontLeak(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("a", GameRules{})
+	require.NoError(t, err)
+	_, err = s.createGame("b", GameRules{})
+	require.NoError(t, err)
+
+	a0 := dialPlayer(t, url, "a", 0)
+	b0 := dialPlayer(t, url, "b", 0)
+	stateA, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](a0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](b0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	action, err := truco.DeserializeAction(stateA.PossibleActions[0])
+	require.NoError(t, err)
+	msg, _ := NewMessageAction(action)
+	require.NoError(t, WsSend(a0, msg))
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](a0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	gameA, _ := s.findGame("a")
+	gameB, _ := s.findGame("b")
+	gameA.do(func() { require.Len(t, gameA.gameState.RoundsLog[1].ActionsLog, 1) })
+	gameB.do(func() {
+		bs, _ := json.Marshal(gameB.gameState.RoundsLog[1].ActionsLog)
+		require.Equal(t, "[]", string(bs))
+	})
+}
+
+func TestRejectedActionsGetAnErrorReply(t *testing.T) {
+	_, url := startTestServer(t)
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	p1 := dialPlayer(t, url, DefaultGameID, 1)
+	state0, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, 0, state0.TurnPlayerID)
+
+	// Player 1 tries to play when it's not their turn.
+	msg, _ := NewMessageAction(truco.NewActionSayTruco(1))
+	require.NoError(t, WsSend(p1, msg))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p1, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeNotYourTurn, msgErr.Code)
+	action, err := truco.DeserializeAction(msgErr.Action)
+	require.NoError(t, err)
+	require.Equal(t, truco.SAY_TRUCO, action.GetName())
+
+	// Player 0 tries an action that isn't possible.
+	msg, _ = NewMessageAction(truco.NewActionSayTrucoQuiero(0))
+	require.NoError(t, WsSend(p0, msg))
+	msgErr, err = WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeActionNotPossible, msgErr.Code)
+
+	// The game state is untouched, and the connection is still usable.
+	require.NoError(t, WsSend(p0, NewMessageGimmeGameState()))
+	state0, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Nil(t, state0.LastActionLog)
+}
+
+func TestActionsForAnotherPlayerAreRejected(t *testing.T) {
+	s, url := startTestServer(t)
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	_, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	// Player 0 tries to play on behalf of player 1.
+	msg, _ := NewMessageAction(truco.NewActionSayTruco(1))
+	require.NoError(t, WsSend(p0, msg))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeWrongPlayer, msgErr.Code)
+
+	// The server is still up, the action wasn't run, and the connection is still usable.
+	require.NoError(t, WsSend(p0, NewMessageGimmeGameState()))
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Nil(t, state.LastActionLog)
+
+	g, err := s.findGame(DefaultGameID)
+	require.NoError(t, err)
+	g.do(func() { require.Len(t, g.gameState.RoundsLog[1].ActionsLog, 0) })
+}
+
+func TestForfeitsFromClientsAreRejected(t *testing.T) {
+	s, url := startTestServer(t)
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	_, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	// Player 0 tries to end the game by forfeiting on their own.
+	msg, _ := NewMessageAction(truco.NewActionForfeit(0))
+	require.NoError(t, WsSend(p0, msg))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeActionNotPossible, msgErr.Code)
+
+	g, err := s.findGame(DefaultGameID)
+	require.NoError(t, err)
+	g.do(func() { require.False(t, g.gameState.IsGameEnded) })
+}
+
+func TestRepeatOffendersAreDisconnected(t *testing.T) {
+	const maxViolations = 3
+	s, url := startTestServer(t, WithMaxViolations(maxViolations))
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	_, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	wrongPlayerAction, _ := NewMessageAction(truco.NewActionSayTruco(1))
+	badMessages := []func() error{
+		func() error { return WsSend(p0, wrongPlayerAction) },
+		func() error { return p0.WriteMessage(websocket.TextMessage, []byte("not json")) },
+		func() error { return WsSend(p0, WebsocketMessage{Type: 1234}) },
+	}
+	expectedCodes := []string{ErrorCodeWrongPlayer, ErrorCodeInvalidMessage, ErrorCodeInvalidMessage}
+	for i, send := range badMessages {
+		require.NoError(t, send())
+		msgErr, err := WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+		require.NoError(t, err)
+		require.Equal(t, expectedCodes[i], msgErr.Code)
+	}
+
+	// The last violation dropped the connection, and freed the slot.
+	_, _, err = p0.ReadMessage()
+	require.Error(t, err)
+	g, err := s.findGame(DefaultGameID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		summary, _ := g.getSummary()
+		return len(summary.OpenSlots) == 2
+	}, time.Second, time.Millisecond)
+
+	// The server is still up, and the player may reconnect.
+	p0 = dialPlayer(t, url, DefaultGameID, 0)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+}
+
+func TestTakeBack(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("casual", GameRules{IsTakeBackEnabled: true})
+	require.NoError(t, err)
+
+	p0 := dialPlayer(t, url, "casual", 0)
+	state0, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	p1 := dialPlayer(t, url, "casual", 1)
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p1, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	// Player 0 reveals a card.
+	msg, _ := NewMessageAction(truco.NewActionRevealCard(state0.YourUnrevealedCards[0], 0))
+	require.NoError(t, WsSend(p0, msg))
+	for _, conn := range []*websocket.Conn{p0, p1} {
+		_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState)
+		require.NoError(t, err)
+	}
+
+	// Player 1 can't take back player 0's action.
+	require.NoError(t, WsSend(p1, NewMessageTakeBackRequest(1)))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p1, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeTakeBackNotAllowed, msgErr.Code)
+
+	// Player 0 asks to take it back, and player 1 rejects it.
+	require.NoError(t, WsSend(p0, NewMessageTakeBackRequest(0)))
+	request, err := WsReadMessage[MessageTakeBackRequest, MessageTakeBackRequest](p1, MessageTypeTakeBackRequest)
+	require.NoError(t, err)
+	require.Equal(t, 0, request.PlayerID)
+	require.NoError(t, WsSend(p1, NewMessageTakeBackResponse(1, false)))
+	for _, conn := range []*websocket.Conn{p0, p1} {
+		response, err := WsReadMessage[MessageTakeBackResponse, MessageTakeBackResponse](conn, MessageTypeTakeBackResponse)
+		require.NoError(t, err)
+		require.False(t, response.Accepted)
+	}
+
+	// Player 0 asks again, and player 1 accepts it this time.
+	require.NoError(t, WsSend(p0, NewMessageTakeBackRequest(0)))
+	_, err = WsReadMessage[MessageTakeBackRequest, MessageTakeBackRequest](p1, MessageTypeTakeBackRequest)
+	require.NoError(t, err)
+	require.NoError(t, WsSend(p1, NewMessageTakeBackResponse(1, true)))
+	for _, conn := range []*websocket.Conn{p0, p1} {
+		response, err := WsReadMessage[MessageTakeBackResponse, MessageTakeBackResponse](conn, MessageTypeTakeBackResponse)
+		require.NoError(t, err)
+		require.True(t, response.Accepted)
+	}
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, state0, state)
+
+	// Only the last action may be taken back, and only once.
+	require.NoError(t, WsSend(p0, NewMessageTakeBackRequest(0)))
+	msgErr, err = WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeTakeBackNotAllowed, msgErr.Code)
+}
+
+func TestTakeBackInTeamGames(t *testing.T) {
+	s, url := startTestServer(t)
+	_, err := s.createGame("teams", GameRules{IsTakeBackEnabled: true, PlayerCount: 4})
+	require.NoError(t, err)
+
+	conns := []*websocket.Conn{}
+	var state0 *truco.ClientGameState
+	for playerID := 0; playerID < 4; playerID++ {
+		conn := dialPlayer(t, url, "teams", playerID)
+		state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState)
+		require.NoError(t, err)
+		if playerID == 0 {
+			state0 = state
+		}
+		conns = append(conns, conn)
+	}
+
+	// Player 0 reveals a card, and asks to take it back.
+	msg, _ := NewMessageAction(truco.NewActionRevealCard(state0.YourUnrevealedCards[0], 0))
+	require.NoError(t, WsSend(conns[0], msg))
+	for _, conn := range conns {
+		_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](conn, MessageTypeHeresGameState)
+		require.NoError(t, err)
+	}
+	require.NoError(t, WsSend(conns[0], NewMessageTakeBackRequest(0)))
+
+	// Both opponents are asked, and player 0's teammate can't reply for them.
+	for _, playerID := range []int{1, 3} {
+		request, err := WsReadMessage[MessageTakeBackRequest, MessageTakeBackRequest](conns[playerID], MessageTypeTakeBackRequest)
+		require.NoError(t, err)
+		require.Equal(t, 0, request.PlayerID)
+	}
+	require.NoError(t, WsSend(conns[2], NewMessageTakeBackResponse(2, true)))
+	msgErr, err := WsReadMessage[MessageError, MessageError](conns[2], MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeTakeBackNotAllowed, msgErr.Code)
+
+	// One opponent accepting isn't enough: the action is only taken back once both accept it.
+	require.NoError(t, WsSend(conns[1], NewMessageTakeBackResponse(1, true)))
+	for _, conn := range conns {
+		response, err := WsReadMessage[MessageTakeBackResponse, MessageTakeBackResponse](conn, MessageTypeTakeBackResponse)
+		require.NoError(t, err)
+		require.Equal(t, 1, response.PlayerID)
+	}
+	require.NoError(t, WsSend(conns[3], NewMessageTakeBackResponse(3, true)))
+	for _, conn := range conns {
+		response, err := WsReadMessage[MessageTakeBackResponse, MessageTakeBackResponse](conn, MessageTypeTakeBackResponse)
+		require.NoError(t, err)
+		require.Equal(t, 3, response.PlayerID)
+		require.True(t, response.Accepted)
+	}
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](conns[0], MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Equal(t, state0, state)
+}
+
+func TestTakeBackIsDisabledByDefault(t *testing.T) {
+	_, url := startTestServer(t)
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	msg, _ := NewMessageAction(truco.NewActionRevealCard(state.YourUnrevealedCards[0], 0))
+	require.NoError(t, WsSend(p0, msg))
+	_, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+
+	require.NoError(t, WsSend(p0, NewMessageTakeBackRequest(0)))
+	msgErr, err := WsReadMessage[MessageError, MessageError](p0, MessageTypeError)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodeTakeBackNotAllowed, msgErr.Code)
+}
+
+func TestEventsAreOnlySentAfterActions(t *testing.T) {
+	_, url := startTestServer(t)
+
+	p0 := dialPlayer(t, url, DefaultGameID, 0)
+	state, err := WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Empty(t, state.Events)
+
+	card := state.YourUnrevealedCards[0]
+	msg, _ := NewMessageAction(truco.NewActionRevealCard(card, 0))
+	require.NoError(t, WsSend(p0, msg))
+	state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Len(t, state.Events, 1)
+	event, err := truco.DeserializeEvent(state.Events[0])
+	require.NoError(t, err)
+	require.Equal(t, truco.EVENT_CARD_PLAYED, event.GetType())
+	require.Equal(t, card, event.(*truco.EventCardPlayed).Card)
+
+	// Asking for the state again doesn't replay them.
+	require.NoError(t, WsSend(p0, NewMessageGimmeGameState()))
+	state, err = WsReadMessage[truco.ClientGameState, MessageHeresGameState](p0, MessageTypeHeresGameState)
+	require.NoError(t, err)
+	require.Empty(t, state.Events)
+}
@@ -0,0 +1,67 @@
+package simulator
+
+import (
+	"fmt"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// maxRoundActions is the number of actions after which a round is considered to never end, when
+// checking invariants. Real rounds take a few dozen actions at most.
+const maxRoundActions = 1000
+
+// invariants checks the rules that must hold after every action of a game, whatever the players
+// do. A violation is a bug in the engine.
+type invariants struct {
+	scores       map[int]int
+	roundNumber  int
+	roundActions int
+}
+
+func newInvariants(gameState *truco.GameState) (*invariants, error) {
+	inv := &invariants{}
+	return inv, inv.check(gameState)
+}
+
+// check checks the invariants on the game state after an action, or at the start of the game.
+func (inv *invariants) check(gameState *truco.GameState) error {
+	for playerID, player := range gameState.Players {
+		if player.Score < inv.scores[playerID] {
+			return fmt.Errorf("player %v's score decreased from %v to %v", playerID, inv.scores[playerID], player.Score)
+		}
+		if player.Score > gameState.Rules.MaxPoints {
+			return fmt.Errorf("player %v's score %v is over the maximum of %v", playerID, player.Score, gameState.Rules.MaxPoints)
+		}
+	}
+	inv.scores = map[int]int{}
+	for playerID, player := range gameState.Players {
+		inv.scores[playerID] = player.Score
+	}
+
+	if gameState.RoundNumber != inv.roundNumber {
+		inv.roundNumber, inv.roundActions = gameState.RoundNumber, 0
+	}
+	inv.roundActions++
+	if inv.roundActions > maxRoundActions {
+		return fmt.Errorf("round %v didn't end after %v actions", gameState.RoundNumber, maxRoundActions)
+	}
+
+	actionsOf := map[int]int{}
+	for _, action := range gameState.CalculatePossibleActions() {
+		actionsOf[action.GetPlayerID()]++
+	}
+	if gameState.IsGameEnded {
+		if len(actionsOf) > 0 {
+			return fmt.Errorf("the game ended, but players %v have actions", actionsOf)
+		}
+		return nil
+	}
+	if actionsOf[gameState.TurnPlayerID] == 0 {
+		return fmt.Errorf("it's player %v's turn, but they have no actions", gameState.TurnPlayerID)
+	}
+	// Once the round is finished, every player left to confirm it can do so.
+	if !gameState.IsRoundFinished && len(actionsOf) > 1 {
+		return fmt.Errorf("it's player %v's turn, but players %v have actions", gameState.TurnPlayerID, actionsOf)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/marianogappa/truco/examplebot/randombot"
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+var weighted = Contender{Name: "weighted", New: func(seed int64) truco.Bot {
+	return randombot.New(randombot.WithSeed(seed), randombot.WithWeights(randombot.RareActionWeights))
+}}
+
+// FuzzEngine plays games between random bots with invariant checks, in every configuration of the
+// game. Run it with go test -fuzz FuzzEngine ./simulator to look for engine bugs.
+func FuzzEngine(f *testing.F) {
+	for seed := int64(0); seed < 4; seed++ {
+		f.Add(seed, uint8(2), false, false)
+		f.Add(seed, uint8(2), true, true)
+		f.Add(seed, uint8(4), false, true)
+		f.Add(seed, uint8(6), true, false)
+	}
+	f.Fuzz(func(t *testing.T, seed int64, playerCount uint8, isFlorEnabled bool, isPicaPica bool) {
+		opts := []func(*truco.GameState){
+			truco.WithPlayerCount([]int{2, 4, 6}[playerCount%3]),
+			truco.WithMaxPoints(15),
+			truco.WithFlorEnabled(isFlorEnabled),
+		}
+		if isPicaPica {
+			opts = append(opts, truco.WithPicaPica(5, 10))
+		}
+		report := New(WithGames(1), WithWorkers(1), WithSeed(seed), WithGameOptions(opts...), WithInvariantChecks(true)).Run(random, weighted)
+		require.Empty(t, report.Errors)
+		require.Equal(t, 1, report.Finished)
+	})
+}
+
+func TestDetectsBrokenInvariants(t *testing.T) {
+	gameState := truco.New(truco.WithSeed(1))
+	inv, err := newInvariants(gameState)
+	require.NoError(t, err)
+
+	gameState.Players[0].Score = 3
+	require.NoError(t, inv.check(gameState))
+
+	gameState.Players[0].Score = 2
+	require.ErrorContains(t, inv.check(gameState), "decreased")
+
+	gameState.Players[0].Score = gameState.Rules.MaxPoints + 1
+	require.ErrorContains(t, inv.check(gameState), "over the maximum")
+
+	gameState.Players[0].Score = 3
+	for i := 0; i < maxRoundActions-2; i++ {
+		require.NoError(t, inv.check(gameState))
+	}
+	require.ErrorContains(t, inv.check(gameState), "didn't end")
+}
@@ -0,0 +1,139 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// z95 is the z-score of a 95% confidence interval.
+const z95 = 1.96
+
+// Report is how each contender did in a simulation.
+type Report struct {
+	// Games is the number of games played, including the ones that crashed.
+	Games int `json:"games"`
+
+	// Finished is the number of games that ended with a winner.
+	Finished int `json:"finished"`
+
+	// EnginePanics is the number of games in which the truco engine panicked, InvariantViolations
+	// the number of games in which it broke an invariant (see WithInvariantChecks), and Unfinished
+	// the number of games given up after too many actions. Bot crashes are counted by contender.
+	EnginePanics        int `json:"enginePanics"`
+	InvariantViolations int `json:"invariantViolations"`
+	Unfinished          int `json:"unfinished"`
+
+	Contenders [2]ContenderReport `json:"contenders"`
+
+	// Errors describes the first crashes, with the seed of their game to reproduce them.
+	Errors []string `json:"errors"`
+}
+
+// ContenderReport is how a contender did in a simulation.
+type ContenderReport struct {
+	Name string `json:"name"`
+
+	// Wins is the number of finished games the contender won.
+	Wins int `json:"wins"`
+
+	// Points and Rounds are the points the contender's team scored in finished games, and the
+	// number of rounds they were scored in.
+	Points int `json:"points"`
+	Rounds int `json:"rounds"`
+
+	// EnvidoAccepted and EnvidoRejected count the contender's responses to envido bids, and
+	// TrucoAccepted and TrucoRejected its responses to truco bids. Raises aren't counted.
+	EnvidoAccepted int `json:"envidoAccepted"`
+	EnvidoRejected int `json:"envidoRejected"`
+	TrucoAccepted  int `json:"trucoAccepted"`
+	TrucoRejected  int `json:"trucoRejected"`
+
+	// Panics is the number of games the contender panicked in, and InvalidActions the number of
+	// games it chose no action or an action that wasn't possible in.
+	Panics         int `json:"panics"`
+	InvalidActions int `json:"invalidActions"`
+}
+
+func (r *Report) add(result gameResult) {
+	switch {
+	case result.enginePanic:
+		r.EnginePanics++
+	case result.invariantViolation:
+		r.InvariantViolations++
+	case result.unfinished:
+		r.Unfinished++
+	case result.winner != -1:
+		r.Finished++
+		r.Contenders[result.winner].Wins++
+	}
+	for i, cr := range result.contenders {
+		c := &r.Contenders[i]
+		if result.winner != -1 {
+			c.Points += cr.points
+			c.Rounds += cr.rounds
+		}
+		c.EnvidoAccepted += cr.envidoAccepted
+		c.EnvidoRejected += cr.envidoRejected
+		c.TrucoAccepted += cr.trucoAccepted
+		c.TrucoRejected += cr.trucoRejected
+		c.Panics += cr.panics
+		c.InvalidActions += cr.invalidActions
+	}
+}
+
+// WinRate returns the fraction of finished games the contender won, and its 95% confidence
+// interval (a Wilson score interval).
+func (r Report) WinRate(contender int) (rate, low, high float64) {
+	n := float64(r.Finished)
+	if n == 0 {
+		return 0, 0, 1
+	}
+	rate = float64(r.Contenders[contender].Wins) / n
+	center := (rate + z95*z95/(2*n)) / (1 + z95*z95/n)
+	margin := z95 / (1 + z95*z95/n) * math.Sqrt(rate*(1-rate)/n+z95*z95/(4*n*n))
+	return rate, max(center-margin, 0), min(center+margin, 1)
+}
+
+// PointsPerRound returns the average points the contender's team scored per round.
+func (c ContenderReport) PointsPerRound() float64 {
+	return ratio(c.Points, c.Rounds)
+}
+
+// EnvidoAcceptanceRate returns the fraction of envido bids the contender accepted.
+func (c ContenderReport) EnvidoAcceptanceRate() float64 {
+	return ratio(c.EnvidoAccepted, c.EnvidoAccepted+c.EnvidoRejected)
+}
+
+// TrucoAcceptanceRate returns the fraction of truco bids the contender accepted.
+func (c ContenderReport) TrucoAcceptanceRate() float64 {
+	return ratio(c.TrucoAccepted, c.TrucoAccepted+c.TrucoRejected)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// String formats the report as a table, e.g. to print it.
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v games, %v finished, %v unfinished, %v engine panics, %v invariant violations\n\n", r.Games, r.Finished, r.Unfinished, r.EnginePanics, r.InvariantViolations)
+	fmt.Fprintf(&sb, "%-12s %8s %13s %9s %8s %8s %7s %8s\n", "bot", "win rate", "95% CI", "pts/round", "envido", "truco", "panics", "invalid")
+	for i, c := range r.Contenders {
+		rate, low, high := r.WinRate(i)
+		ci := fmt.Sprintf("%.1f%%-%.1f%%", low*100, high*100)
+		fmt.Fprintf(&sb, "%-12s %7.1f%% %13s %9.2f %7.1f%% %7.1f%% %7d %8d\n",
+			c.Name, rate*100, ci, c.PointsPerRound(),
+			c.EnvidoAcceptanceRate()*100, c.TrucoAcceptanceRate()*100, c.Panics, c.InvalidActions)
+	}
+	if len(r.Errors) > 0 {
+		sb.WriteString("\nerrors:\n")
+		for _, err := range r.Errors {
+			fmt.Fprintf(&sb, "  %v\n", err)
+		}
+	}
+	return sb.String()
+}
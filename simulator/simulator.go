@@ -0,0 +1,262 @@
+// Package simulator plays games between bots in-process, without a server nor clients, to find
+// out which bot is better.
+//
+// Games are played in parallel, each with its own seed, and the contenders swap teams on every
+// game, so that neither of them has the advantage of being mano more often.
+package simulator
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/marianogappa/truco/truco"
+)
+
+// DefaultGames is the number of games played if WithGames isn't used.
+const DefaultGames = 1000
+
+// DefaultMaxActions is the number of actions after which a game is given up as unfinished, if
+// WithMaxActions isn't used. No real game gets close to it.
+const DefaultMaxActions = 10000
+
+// maxErrors is the number of crashes the report describes; the rest are only counted.
+const maxErrors = 10
+
+// Contender is a bot that plays in a simulation.
+type Contender struct {
+	Name string
+
+	// New returns a bot for a player of a game. Random bots should use the given seed, so that
+	// games can be reproduced.
+	New func(seed int64) truco.Bot
+}
+
+type Simulator struct {
+	games           int
+	workers         int
+	seed            int64
+	maxActions      int
+	gameOptions     []func(*truco.GameState)
+	checkInvariants bool
+}
+
+// WithGames sets the number of games to play.
+func WithGames(games int) func(*Simulator) {
+	return func(s *Simulator) {
+		s.games = games
+	}
+}
+
+// WithWorkers sets the number of games played in parallel. It defaults to the number of CPUs.
+func WithWorkers(workers int) func(*Simulator) {
+	return func(s *Simulator) {
+		s.workers = workers
+	}
+}
+
+// WithSeed sets the seed of the first game; each game after it uses the next seed.
+func WithSeed(seed int64) func(*Simulator) {
+	return func(s *Simulator) {
+		s.seed = seed
+	}
+}
+
+// WithMaxActions sets the number of actions after which a game is given up as unfinished.
+func WithMaxActions(maxActions int) func(*Simulator) {
+	return func(s *Simulator) {
+		s.maxActions = maxActions
+	}
+}
+
+// WithGameOptions sets the options of every game, e.g. truco.WithPlayerCount(4). truco.WithSeed is
+// set by the simulator.
+func WithGameOptions(opts ...func(*truco.GameState)) func(*Simulator) {
+	return func(s *Simulator) {
+		s.gameOptions = opts
+	}
+}
+
+// WithInvariantChecks makes the simulator check, after every action, the rules that the engine
+// must uphold whatever the players do, e.g. that scores never decrease. A game in which they are
+// broken is stopped, and counted in Report.InvariantViolations. It slows games down.
+func WithInvariantChecks(checkInvariants bool) func(*Simulator) {
+	return func(s *Simulator) {
+		s.checkInvariants = checkInvariants
+	}
+}
+
+func New(opts ...func(*Simulator)) *Simulator {
+	s := &Simulator{games: DefaultGames, workers: runtime.NumCPU(), seed: 1, maxActions: DefaultMaxActions}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run plays the games between the two contenders, and reports how each of them did.
+func (s *Simulator) Run(a, b Contender) Report {
+	var (
+		contenders = [2]Contender{a, b}
+		gameIDs    = make(chan int)
+		results    = make(chan gameResult)
+		wg         sync.WaitGroup
+	)
+	for i := 0; i < max(s.workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for gameID := range gameIDs {
+				results <- s.play(contenders, gameID)
+			}
+		}()
+	}
+	go func() {
+		for gameID := 0; gameID < s.games; gameID++ {
+			gameIDs <- gameID
+		}
+		close(gameIDs)
+		wg.Wait()
+		close(results)
+	}()
+
+	report := Report{Games: s.games}
+	for i, contender := range contenders {
+		report.Contenders[i].Name = contender.Name
+	}
+	crashes := []gameResult{}
+	for result := range results {
+		report.add(result)
+		if result.err != nil {
+			crashes = append(crashes, result)
+		}
+	}
+	sort.Slice(crashes, func(i, j int) bool { return crashes[i].seed < crashes[j].seed })
+	for _, crash := range crashes[:min(len(crashes), maxErrors)] {
+		report.Errors = append(report.Errors, fmt.Sprintf("game with seed %v: %v", crash.seed, crash.err))
+	}
+	return report
+}
+
+// gameResult is what happened in a game, from the point of view of each contender.
+type gameResult struct {
+	seed   int64
+	winner int // index of the winning contender, or -1 if the game didn't finish
+	err    error
+
+	unfinished         bool
+	enginePanic        bool
+	invariantViolation bool
+	contenders         [2]contenderResult
+}
+
+type contenderResult struct {
+	points         int
+	rounds         int
+	envidoAccepted int
+	envidoRejected int
+	trucoAccepted  int
+	trucoRejected  int
+	panics         int
+	invalidActions int
+}
+
+// play plays a game, with the first contender on the first team on even games, and on the second
+// team on odd ones.
+func (s *Simulator) play(contenders [2]Contender, gameID int) (result gameResult) {
+	seed := s.seed + int64(gameID)
+	result = gameResult{seed: seed, winner: -1}
+	defer func() {
+		// Bot panics are recovered in chooseAction, so this one is the engine's.
+		if r := recover(); r != nil {
+			result.enginePanic = true
+			result.err = fmt.Errorf("the engine panicked: %v", r)
+		}
+	}()
+
+	gameState := truco.New(append(append([]func(*truco.GameState){}, s.gameOptions...), truco.WithSeed(seed))...)
+	contenderOf := func(playerID int) int {
+		return (gameState.TeamOf(playerID) + gameID) % 2
+	}
+	bots := map[int]truco.Bot{}
+	for playerID := range gameState.Players {
+		bots[playerID] = contenders[contenderOf(playerID)].New(seed*int64(len(gameState.Players)) + int64(playerID))
+	}
+
+	var inv *invariants
+	if s.checkInvariants {
+		var err error
+		if inv, err = newInvariants(gameState); err != nil {
+			result.invariantViolation = true
+			result.err = fmt.Errorf("invariant violated at the start: %w", err)
+			return result
+		}
+	}
+
+	for actions := 0; !gameState.IsGameEnded; actions++ {
+		if actions >= s.maxActions {
+			result.unfinished = true
+			result.err = fmt.Errorf("the game didn't finish after %v actions", s.maxActions)
+			return result
+		}
+		var (
+			playerID  = gameState.TurnPlayerID
+			contender = contenderOf(playerID)
+			name      = contenders[contender].Name
+		)
+		action, err := chooseAction(bots[playerID], gameState.ToClientGameState(playerID))
+		if err != nil {
+			result.contenders[contender].panics++
+			result.err = fmt.Errorf("%v panicked: %w", name, err)
+			return result
+		}
+		if action == nil {
+			result.contenders[contender].invalidActions++
+			result.err = fmt.Errorf("%v chose no action", name)
+			return result
+		}
+		if err := gameState.RunAction(action); err != nil {
+			result.contenders[contender].invalidActions++
+			result.err = fmt.Errorf("%v chose an invalid action %s: %w", name, truco.SerializeAction(action), err)
+			return result
+		}
+		if inv != nil {
+			if err := inv.check(gameState); err != nil {
+				result.invariantViolation = true
+				result.err = fmt.Errorf("invariant violated after %s: %w", truco.SerializeAction(action), err)
+				return result
+			}
+		}
+		switch action.GetName() {
+		case truco.SAY_ENVIDO_QUIERO:
+			result.contenders[contender].envidoAccepted++
+		case truco.SAY_ENVIDO_NO_QUIERO:
+			result.contenders[contender].envidoRejected++
+		case truco.SAY_TRUCO_QUIERO:
+			result.contenders[contender].trucoAccepted++
+		case truco.SAY_TRUCO_NO_QUIERO:
+			result.contenders[contender].trucoRejected++
+		}
+	}
+
+	result.winner = contenderOf(gameState.WinnerPlayerID)
+	for _, roundLog := range gameState.RoundsLog[1:] {
+		for team, points := range roundLog.TeamPoints {
+			contender := (team + gameID) % 2
+			result.contenders[contender].points += points
+			result.contenders[contender].rounds++
+		}
+	}
+	return result
+}
+
+// chooseAction asks the bot for an action, and returns an error if it panics.
+func chooseAction(bot truco.Bot, gs truco.ClientGameState) (action truco.Action, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return bot.ChooseAction(gs), nil
+}
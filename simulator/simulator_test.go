@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/marianogappa/truco/examplebot/randombot"
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+var random = Contender{Name: "random", New: func(seed int64) truco.Bot { return randombot.New(randombot.WithSeed(seed)) }}
+
+type panickingBot struct{}
+
+func (panickingBot) ChooseAction(truco.ClientGameState) truco.Action { panic("oops") }
+
+type passiveBot struct{}
+
+func (passiveBot) ChooseAction(truco.ClientGameState) truco.Action { return nil }
+
+func TestRunsGamesBetweenBots(t *testing.T) {
+	configs := []struct {
+		name string
+		opts []func(*truco.GameState)
+	}{
+		{name: "two players", opts: []func(*truco.GameState){truco.WithMaxPoints(15)}},
+		{name: "four players with flor", opts: []func(*truco.GameState){truco.WithMaxPoints(15), truco.WithPlayerCount(4), truco.WithFlorEnabled(true)}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			s := New(WithGames(40), WithWorkers(4), WithGameOptions(config.opts...))
+			report := s.Run(random, random)
+
+			require.Equal(t, 40, report.Games)
+			require.Equal(t, 40, report.Finished)
+			require.Empty(t, report.Errors)
+			require.Equal(t, 40, report.Contenders[0].Wins+report.Contenders[1].Wins)
+			for _, c := range report.Contenders {
+				require.Greater(t, c.Rounds, 0)
+				require.Greater(t, c.PointsPerRound(), 0.0)
+			}
+
+			// Random bots with the same seeds play the same games.
+			require.Equal(t, report, s.Run(random, random))
+		})
+	}
+}
+
+func TestCountsCrashes(t *testing.T) {
+	var (
+		panicking = Contender{Name: "panicking", New: func(int64) truco.Bot { return panickingBot{} }}
+		passive   = Contender{Name: "passive", New: func(int64) truco.Bot { return passiveBot{} }}
+	)
+	report := New(WithGames(10)).Run(panicking, passive)
+
+	require.Equal(t, 0, report.Finished)
+	// The mano plays first, and the contenders take turns being on the mano's team.
+	require.Equal(t, 5, report.Contenders[0].Panics)
+	require.Equal(t, 5, report.Contenders[1].InvalidActions)
+	require.Len(t, report.Errors, 10)
+	require.Equal(t, "game with seed 1: panicking panicked: oops", report.Errors[0])
+	require.Equal(t, "game with seed 2: passive chose no action", report.Errors[1])
+}
+
+func TestWinRateConfidenceInterval(t *testing.T) {
+	report := Report{Finished: 100, Contenders: [2]ContenderReport{{Wins: 60}, {Wins: 40}}}
+	rate, low, high := report.WinRate(0)
+	require.InDelta(t, 0.6, rate, 1e-9)
+	require.InDelta(t, 0.502, low, 1e-3)
+	require.InDelta(t, 0.691, high, 1e-3)
+
+	_, low, high = Report{}.WinRate(0)
+	require.Equal(t, 0.0, low)
+	require.Equal(t, 1.0, high)
+}
@@ -0,0 +1,365 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tournament formats.
+const (
+	// RoundRobin plays a match between every pair of contenders.
+	RoundRobin = "round-robin"
+
+	// Swiss plays a number of rounds in which contenders with similar results play each other,
+	// which needs far fewer matches than RoundRobin with many contenders.
+	Swiss = "swiss"
+)
+
+// InitialRating is the average rating of the contenders of a tournament.
+const InitialRating = 1500
+
+// ratingIterations is the number of iterations used to fit the ratings to the results, which is
+// plenty for them to converge.
+const ratingIterations = 1000
+
+// Tournament plays matches between many contenders, each match being a simulation of its own
+// between two of them, and ranks them by Elo rating.
+type Tournament struct {
+	simulator *Simulator
+	format    string
+	rounds    int
+}
+
+// WithFormat sets the format of the tournament: RoundRobin (the default) or Swiss.
+func WithFormat(format string) func(*Tournament) {
+	return func(t *Tournament) {
+		t.format = format
+	}
+}
+
+// WithRounds sets the number of rounds of a Swiss tournament. It defaults to the number of rounds
+// needed to tell the best contender apart, i.e. log2 of the number of contenders.
+func WithRounds(rounds int) func(*Tournament) {
+	return func(t *Tournament) {
+		t.rounds = rounds
+	}
+}
+
+// NewTournament returns a tournament that plays its matches with the given simulator. Every match
+// has its own seeds, so matches don't replay the same games.
+func NewTournament(simulator *Simulator, opts ...func(*Tournament)) *Tournament {
+	t := &Tournament{simulator: simulator, format: RoundRobin}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// TournamentResult is the outcome of a tournament.
+type TournamentResult struct {
+	Format string `json:"format"`
+
+	// Leaderboard has a standing for every contender, from the highest rating to the lowest.
+	Leaderboard []Standing `json:"leaderboard"`
+
+	// Contenders has the names of the contenders, in the order they were given to the tournament.
+	// HeadToHead[i][j] is the number of games the i-th contender won against the j-th, and
+	// Games[i][j] the number of finished games between them.
+	Contenders []string `json:"contenders"`
+	HeadToHead [][]int  `json:"headToHead"`
+	Games      [][]int  `json:"games"`
+
+	Matches []Match `json:"matches"`
+}
+
+// Standing is how a contender did in a tournament.
+type Standing struct {
+	Name string `json:"name"`
+
+	// Rating is the contender's Elo rating, fit to the results of all of its games.
+	Rating float64 `json:"rating"`
+
+	// Games and Wins count finished games, and Crashes the games the contender panicked or chose
+	// an invalid action in.
+	Games   int `json:"games"`
+	Wins    int `json:"wins"`
+	Crashes int `json:"crashes"`
+}
+
+// Match is a simulation between two contenders of a tournament.
+type Match struct {
+	Round  int    `json:"round"`
+	Report Report `json:"report"`
+}
+
+// Run plays the tournament between the given contenders, which must have different names.
+func (t *Tournament) Run(contenders []Contender) (TournamentResult, error) {
+	if len(contenders) < 2 {
+		return TournamentResult{}, fmt.Errorf("a tournament needs at least 2 contenders, got %v", len(contenders))
+	}
+	names := map[string]bool{}
+	for _, contender := range contenders {
+		if names[contender.Name] {
+			return TournamentResult{}, fmt.Errorf("there are two contenders named %v", contender.Name)
+		}
+		names[contender.Name] = true
+	}
+
+	result := TournamentResult{
+		Format:     t.format,
+		HeadToHead: newMatrix(len(contenders)),
+		Games:      newMatrix(len(contenders)),
+	}
+	for _, contender := range contenders {
+		result.Contenders = append(result.Contenders, contender.Name)
+	}
+
+	var rounds [][][2]int
+	switch t.format {
+	case RoundRobin:
+		rounds = [][][2]int{roundRobinPairings(len(contenders))}
+	case Swiss:
+		rounds = make([][][2]int, t.swissRounds(len(contenders)))
+	default:
+		return TournamentResult{}, fmt.Errorf("unknown tournament format %v", t.format)
+	}
+
+	var (
+		played = newMatrix(len(contenders))
+		byes   = make([]bool, len(contenders))
+	)
+	for round := range rounds {
+		pairings := rounds[round]
+		if t.format == Swiss {
+			pairings = swissPairings(result.Games, result.HeadToHead, played, byes)
+		}
+		for _, pairing := range pairings {
+			i, j := pairing[0], pairing[1]
+			simulator := *t.simulator
+			simulator.seed += int64(len(result.Matches) * simulator.games)
+			report := simulator.Run(contenders[i], contenders[j])
+
+			result.Matches = append(result.Matches, Match{Round: round + 1, Report: report})
+			result.HeadToHead[i][j] += report.Contenders[0].Wins
+			result.HeadToHead[j][i] += report.Contenders[1].Wins
+			result.Games[i][j] += report.Finished
+			result.Games[j][i] += report.Finished
+			played[i][j], played[j][i] = 1, 1
+		}
+	}
+
+	ratings := fitRatings(result.HeadToHead, result.Games)
+	for i, name := range result.Contenders {
+		standing := Standing{Name: name, Rating: ratings[i]}
+		for j := range result.Contenders {
+			standing.Games += result.Games[i][j]
+			standing.Wins += result.HeadToHead[i][j]
+		}
+		for _, match := range result.Matches {
+			for _, c := range match.Report.Contenders {
+				if c.Name == name {
+					standing.Crashes += c.Panics + c.InvalidActions
+				}
+			}
+		}
+		result.Leaderboard = append(result.Leaderboard, standing)
+	}
+	sort.SliceStable(result.Leaderboard, func(i, j int) bool { return result.Leaderboard[i].Rating > result.Leaderboard[j].Rating })
+	return result, nil
+}
+
+func (t *Tournament) swissRounds(contenders int) int {
+	if t.rounds > 0 {
+		return t.rounds
+	}
+	return int(math.Ceil(math.Log2(float64(contenders))))
+}
+
+func newMatrix(size int) [][]int {
+	matrix := make([][]int, size)
+	for i := range matrix {
+		matrix[i] = make([]int, size)
+	}
+	return matrix
+}
+
+func roundRobinPairings(contenders int) [][2]int {
+	pairings := [][2]int{}
+	for i := 0; i < contenders; i++ {
+		for j := i + 1; j < contenders; j++ {
+			pairings = append(pairings, [2]int{i, j})
+		}
+	}
+	return pairings
+}
+
+// swissPairings pairs contenders with the closest win rates that haven't played each other yet, if
+// possible. With an odd number of contenders, the lowest ranked one that hasn't had a bye yet
+// doesn't play this round.
+func swissPairings(games, wins, played [][]int, byes []bool) [][2]int {
+	ranking := make([]int, len(games))
+	winRates := make([]float64, len(games))
+	for i := range ranking {
+		ranking[i] = i
+		var g, w int
+		for j := range games {
+			g += games[i][j]
+			w += wins[i][j]
+		}
+		winRates[i] = ratio(w, g)
+	}
+	sort.SliceStable(ranking, func(a, b int) bool { return winRates[ranking[a]] > winRates[ranking[b]] })
+
+	if len(ranking)%2 == 1 {
+		bye := len(ranking) - 1
+		for k := len(ranking) - 1; k >= 0; k-- {
+			if !byes[ranking[k]] {
+				bye = k
+				break
+			}
+		}
+		byes[ranking[bye]] = true
+		ranking = append(ranking[:bye:bye], ranking[bye+1:]...)
+	}
+
+	pairings := [][2]int{}
+	paired := make([]bool, len(games))
+	for a, i := range ranking {
+		if paired[i] {
+			continue
+		}
+		opponent := -1
+		for _, j := range ranking[a+1:] {
+			if paired[j] {
+				continue
+			}
+			if opponent == -1 {
+				opponent = j
+			}
+			if played[i][j] == 0 {
+				opponent = j
+				break
+			}
+		}
+		paired[i], paired[opponent] = true, true
+		pairings = append(pairings, [2]int{i, opponent})
+	}
+	return pairings
+}
+
+// fitRatings returns the Elo ratings that explain the results best (the maximum likelihood
+// estimate of the Bradley-Terry model, on the Elo scale), with an average of InitialRating.
+//
+// Unlike updating ratings after every game, the result doesn't depend on the order of the games.
+// Every pair of contenders that played is counted as having drawn one more game, so that a
+// contender that never won doesn't have a rating of minus infinity.
+func fitRatings(wins, games [][]int) []float64 {
+	strengths := make([]float64, len(games))
+	for i := range strengths {
+		strengths[i] = 1
+	}
+	for iteration := 0; iteration < ratingIterations; iteration++ {
+		next := make([]float64, len(strengths))
+		logSum := 0.0
+		for i := range strengths {
+			var won, expected float64
+			for j := range strengths {
+				if i == j || games[i][j] == 0 {
+					continue
+				}
+				won += float64(wins[i][j]) + 0.5
+				expected += float64(games[i][j]+1) / (strengths[i] + strengths[j])
+			}
+			next[i] = strengths[i]
+			if expected > 0 {
+				next[i] = won / expected
+			}
+			logSum += math.Log(next[i])
+		}
+		mean := math.Exp(logSum / float64(len(next)))
+		for i := range next {
+			strengths[i] = next[i] / mean
+		}
+	}
+
+	ratings := make([]float64, len(strengths))
+	for i, strength := range strengths {
+		ratings[i] = InitialRating + 400*math.Log10(strength)
+	}
+	return ratings
+}
+
+// String formats the leaderboard and the head-to-head results as tables, e.g. to print them.
+func (r TournamentResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-4s %-12s %7s %7s %9s %8s\n", "rank", "bot", "rating", "games", "win rate", "crashes")
+	for rank, s := range r.Leaderboard {
+		fmt.Fprintf(&sb, "%-4d %-12s %7.0f %7d %8.1f%% %8d\n", rank+1, s.Name, s.Rating, s.Games, ratio(s.Wins, s.Games)*100, s.Crashes)
+	}
+
+	sb.WriteString("\nwins (row) against (column):\n")
+	fmt.Fprintf(&sb, "%-12s", "")
+	for _, name := range r.Contenders {
+		fmt.Fprintf(&sb, " %12s", name)
+	}
+	sb.WriteString("\n")
+	for i, name := range r.Contenders {
+		fmt.Fprintf(&sb, "%-12s", name)
+		for j := range r.Contenders {
+			cell := "-"
+			if r.Games[i][j] > 0 {
+				cell = fmt.Sprintf("%v/%v", r.HeadToHead[i][j], r.Games[i][j])
+			}
+			fmt.Fprintf(&sb, " %12s", cell)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// WriteJSON writes the whole result as JSON, including every match's report.
+func (r TournamentResult) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the leaderboard as CSV, with a column for the wins against each contender.
+func (r TournamentResult) WriteCSV(w io.Writer) error {
+	var (
+		writer = csv.NewWriter(w)
+		index  = map[string]int{}
+		header = []string{"rank", "bot", "rating", "games", "wins", "crashes"}
+	)
+	for i, name := range r.Contenders {
+		index[name] = i
+		header = append(header, "wins against "+name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for rank, s := range r.Leaderboard {
+		record := []string{
+			strconv.Itoa(rank + 1),
+			s.Name,
+			strconv.FormatFloat(s.Rating, 'f', 1, 64),
+			strconv.Itoa(s.Games),
+			strconv.Itoa(s.Wins),
+			strconv.Itoa(s.Crashes),
+		}
+		for _, wins := range r.HeadToHead[index[s.Name]] {
+			record = append(record, strconv.Itoa(wins))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
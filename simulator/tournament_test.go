@@ -0,0 +1,98 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/marianogappa/truco/examplebot/newbot"
+	"github.com/marianogappa/truco/truco"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundRobinTournament(t *testing.T) {
+	var (
+		strong      = Contender{Name: "newbot", New: func(int64) truco.Bot { return newbot.New() }}
+		random2     = Contender{Name: "random2", New: random.New}
+		simulator   = New(WithGames(10), WithGameOptions(truco.WithMaxPoints(15)))
+		result, err = NewTournament(simulator).Run([]Contender{random, strong, random2})
+	)
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 3)
+	require.Equal(t, []string{"random", "newbot", "random2"}, result.Contenders)
+	for i := range result.Contenders {
+		for j := range result.Contenders {
+			require.Equal(t, result.Games[i][j], result.HeadToHead[i][j]+result.HeadToHead[j][i])
+			if i != j {
+				require.Equal(t, 10, result.Games[i][j])
+			}
+		}
+	}
+	require.Equal(t, "newbot", result.Leaderboard[0].Name)
+	require.Greater(t, result.Leaderboard[0].Rating, float64(InitialRating))
+	require.Equal(t, 20, result.Leaderboard[0].Games)
+
+	// Every match has its own games.
+	require.NotEqual(t, result.Matches[0].Report, result.Matches[2].Report)
+
+	var decoded TournamentResult
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteJSON(&buf))
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, result.Leaderboard, decoded.Leaderboard)
+
+	buf.Reset()
+	require.NoError(t, result.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "rank,bot,rating,games,wins,crashes,wins against random,wins against newbot,wins against random2", lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "1,newbot,"))
+}
+
+func TestSwissTournament(t *testing.T) {
+	contenders := []Contender{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		contenders = append(contenders, Contender{Name: name, New: random.New})
+	}
+	result, err := NewTournament(New(WithGames(4), WithGameOptions(truco.WithMaxPoints(15))), WithFormat(Swiss)).Run(contenders)
+	require.NoError(t, err)
+
+	// 3 rounds of 2 matches, as one of the 5 contenders rests every round.
+	require.Len(t, result.Matches, 6)
+	for i := range result.Contenders {
+		matches := 0
+		for j := range result.Contenders {
+			if result.Games[i][j] > 0 {
+				matches++
+				// Nobody plays the same opponent twice, since it can be avoided.
+				require.Equal(t, 4, result.Games[i][j])
+			}
+		}
+		require.GreaterOrEqual(t, matches, 2)
+	}
+}
+
+func TestTournamentErrors(t *testing.T) {
+	_, err := NewTournament(New()).Run([]Contender{random})
+	require.Error(t, err)
+
+	_, err = NewTournament(New()).Run([]Contender{random, random})
+	require.Error(t, err)
+
+	_, err = NewTournament(New(), WithFormat("knockout")).Run([]Contender{random, {Name: "other", New: random.New}})
+	require.Error(t, err)
+}
+
+func TestFitRatings(t *testing.T) {
+	// a beats b 3 times out of 4, and b beats c 3 times out of 4.
+	wins := [][]int{{0, 300, 0}, {100, 0, 300}, {0, 100, 0}}
+	games := [][]int{{0, 400, 0}, {400, 0, 400}, {0, 400, 0}}
+	ratings := fitRatings(wins, games)
+
+	// A 75% win rate is a difference of about 191 points.
+	require.InDelta(t, 191, ratings[0]-ratings[1], 2)
+	require.InDelta(t, 191, ratings[1]-ratings[2], 2)
+	require.InDelta(t, InitialRating, (ratings[0]+ratings[1]+ratings[2])/3, 1e-6)
+}
@@ -91,23 +91,24 @@ func (a ActionSayTrucoNoQuiero) IsPossible(g GameState) bool {
 
 func (a ActionSayEnvidoNoQuiero) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.EnvidoSequence.AddStep(a.GetName())
 	g.IsEnvidoFinished = true
-	cost, err := g.EnvidoSequence.Cost(g.RuleMaxPoints, g.Players[g.TurnPlayerID].Score, g.Players[g.TurnOpponentPlayerID].Score, false)
+	cost, err := g.EnvidoSequence.Cost(g.Rules, g.Players[g.TurnPlayerID].Score, g.Players[g.rivalPlayerID()].Score, false)
 	if err != nil {
 		return err
 	}
 	g.RoundsLog[g.RoundNumber].EnvidoPoints = cost
-	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.TurnOpponentPlayerID
-	g.Players[g.TurnOpponentPlayerID].Score += cost
+	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.rivalPlayerID()
+	g.emitEnvidoResolved()
+	g.addPoints(g.rivalPlayerID(), cost, POINTS_REASON_ENVIDO)
 	return nil
 }
 
 func (a ActionSayEnvidoQuiero) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.EnvidoSequence.AddStep(a.GetName())
 	return nil
@@ -115,7 +116,7 @@ func (a ActionSayEnvidoQuiero) Run(g *GameState) error {
 
 func (a ActionSayTrucoQuiero) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.TrucoSequence.AddStep(a.GetName())
 	g.TrucoSequence.QuieroOwnerPlayerID = g.TurnPlayerID
@@ -124,14 +125,14 @@ func (a ActionSayTrucoQuiero) Run(g *GameState) error {
 
 func (a ActionSayTrucoNoQuiero) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.TrucoSequence.AddStep(a.GetName())
 	g.IsRoundFinished = true
 	cost := g.TrucoSequence.Cost()
 	g.RoundsLog[g.RoundNumber].TrucoPoints = cost
-	g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = g.TurnOpponentPlayerID
-	g.Players[g.TurnOpponentPlayerID].Score += cost
+	g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = g.rivalPlayerID()
+	g.addPoints(g.rivalPlayerID(), cost, POINTS_REASON_TRUCO)
 	return nil
 }
 
@@ -142,23 +143,35 @@ func (a ActionSayTrucoQuiero) YieldsTurn(g GameState) bool {
 	return g.TurnPlayerID != g.TrucoSequence.StartingPlayerID
 }
 
+func (a ActionSayTrucoQuiero) nextTurnPlayerID(g GameState) int {
+	return g.TrucoSequence.StartingPlayerID
+}
+
 func (a ActionSayEnvidoNoQuiero) YieldsTurn(g GameState) bool {
 	// In son_buenas/son_mejores/no_quiero, the turn should go to whoever started the sequence
 	return g.TurnPlayerID != g.EnvidoSequence.StartingPlayerID
 }
 
+func (a ActionSayEnvidoNoQuiero) nextTurnPlayerID(g GameState) int {
+	return g.EnvidoSequence.StartingPlayerID
+}
+
 func (a ActionSayEnvidoQuiero) YieldsTurn(g GameState) bool {
 	// In envido_quiero, the next turn should go to whoever has to reveal the score.
 	// This should always be the "mano" player.
 	return g.TurnPlayerID != g.RoundTurnPlayerID
 }
 
+func (a ActionSayEnvidoQuiero) nextTurnPlayerID(g GameState) int {
+	return g.RoundTurnPlayerID
+}
+
 func (a *ActionSayTrucoQuiero) Enrich(g GameState) {
 	a.RequiresReminder = _doesTrucoActionRequireReminder(g)
 	quieroSeq, _ := g.TrucoSequence.WithStep(SAY_TRUCO_QUIERO)
 	quieroCost := quieroSeq.Cost()
 	a.Cost = quieroCost
-	a.Forced = g.Players[g.OpponentOf(a.PlayerID)].Score == g.RuleMaxPoints-1
+	a.Forced = g.Players[g.OpponentOf(a.PlayerID)].Score == g.Rules.MaxPoints-1
 }
 
 func (a *ActionSayTrucoNoQuiero) Enrich(g GameState) {
@@ -176,7 +189,7 @@ func (a *ActionSayEnvidoQuiero) Enrich(g GameState) {
 		youScore         = g.Players[a.GetPlayerID()].Score
 		theirScore       = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		quieroSeq, err   = g.EnvidoSequence.WithStep(SAY_ENVIDO_QUIERO)
-		quieroCost, err2 = quieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err2 = quieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err != nil {
 		panic(err)
@@ -185,7 +198,7 @@ func (a *ActionSayEnvidoQuiero) Enrich(g GameState) {
 		panic(err2)
 	}
 	a.Cost = quieroCost
-	a.Forced = g.Players[g.OpponentOf(a.PlayerID)].Score == g.RuleMaxPoints-1
+	a.Forced = g.Players[g.OpponentOf(a.PlayerID)].Score == g.Rules.MaxPoints-1
 }
 
 func (a *ActionSayEnvidoNoQuiero) Enrich(g GameState) {
@@ -196,7 +209,7 @@ func (a *ActionSayEnvidoNoQuiero) Enrich(g GameState) {
 		youScore           = g.Players[a.GetPlayerID()].Score
 		theirScore         = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		noQuieroSeq, err   = g.EnvidoSequence.WithStep(SAY_ENVIDO_NO_QUIERO)
-		noQuieroCost, err2 = noQuieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		noQuieroCost, err2 = noQuieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err != nil {
 		panic(err)
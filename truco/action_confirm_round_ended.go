@@ -12,7 +12,7 @@ func (a ActionConfirmRoundFinished) IsPossible(g GameState) bool {
 
 func (a ActionConfirmRoundFinished) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.RoundFinishedConfirmedPlayerIDs[a.PlayerID] = true
 	return nil
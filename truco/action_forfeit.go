@@ -0,0 +1,28 @@
+package truco
+
+import "fmt"
+
+// FORFEIT isn't part of a truco game: it's never a possible action, but it can always be run,
+// e.g. by a server when a player runs out of time, or when a player resigns. Since it can always be
+// run, servers must not run it on behalf of clients that send it.
+const FORFEIT = "forfeit"
+
+// ActionForfeit ends the game, and the opponent of the player who forfeits wins it.
+type ActionForfeit struct {
+	act
+}
+
+func (a ActionForfeit) IsPossible(g GameState) bool {
+	return !g.IsGameEnded
+}
+
+func (a ActionForfeit) Run(g *GameState) error {
+	// RunAction ends the game once a player reaches the max points.
+	g.addPoints(g.OpponentOf(a.PlayerID), g.Rules.MaxPoints, POINTS_REASON_FORFEIT)
+	g.IsRoundFinished = true
+	return nil
+}
+
+func (a ActionForfeit) String() string {
+	return fmt.Sprintf("Player %v forfeits the game", a.PlayerID)
+}
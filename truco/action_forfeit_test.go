@@ -0,0 +1,23 @@
+package truco
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForfeit(t *testing.T) {
+	gameState := New(WithMaxPoints(15))
+	require.NoError(t, gameState.RunAction(NewActionSayTruco(gameState.TurnPlayerID)))
+
+	forfeiter := gameState.TurnPlayerID
+	require.NoError(t, gameState.RunAction(NewActionForfeit(forfeiter)))
+
+	require.True(t, gameState.IsGameEnded)
+	require.Equal(t, gameState.OpponentOf(forfeiter), gameState.WinnerPlayerID)
+	require.Equal(t, 15, gameState.Players[gameState.OpponentOf(forfeiter)].Score)
+
+	action, err := DeserializeAction(SerializeAction(NewActionForfeit(forfeiter)))
+	require.NoError(t, err)
+	require.Equal(t, NewActionForfeit(forfeiter), action)
+}
@@ -1,5 +1,7 @@
 package truco
 
+import "fmt"
+
 type ActionSayMeVoyAlMazo struct {
 	act
 }
@@ -20,41 +22,65 @@ func (a ActionSayMeVoyAlMazo) IsPossible(g GameState) bool {
 	if NewActionRevealFlorScore(a.PlayerID).IsPossible(g) || NewActionRevealEnvidoScore(a.PlayerID).IsPossible(g) {
 		return false
 	}
+	if g.Rules.IsMazoForbiddenDuringEnvido && g.EnvidoSequence.WasAccepted() && !g.EnvidoSequence.EnvidoPointsAwarded {
+		return false
+	}
 	return true
 }
 
 func (a ActionSayMeVoyAlMazo) Run(g *GameState) error {
-	cost := func() int {
-		if g.TrucoSequence.IsEmpty() {
-			if g.IsEnvidoFinished {
-				// In this case:
-				// - Envido was played, "no quiero" happened and score was updated already.
-				// - Envido was played, "quiero" happened and score either was or will be updated after this.
-				// - Envido wasn't played, and it "expired" after first faceoff.
-				//
-				// In all cases, the cost is 0 for envido, so just 1 point for truco.
-				return 1
-			}
-			// Envido is not finished. This can only happen if it wasn't played but can still be.
-			// So the cost is 1 point for envido and 1 point for truco.
-			return 2
-		}
-		// If truco was played, let's start by calculating its cost
-		_cost := g.TrucoSequence.Cost()
-		// If envido wasn't finished (wasn't played but can still be), we must add 1 point
-		if !g.IsEnvidoFinished {
-			_cost++
-		}
-		return _cost
-	}()
+	mazo := &MazoLog{PlayerID: a.PlayerID, TrucoPoints: 1}
+	// If truco was played, it costs what a "no quiero" would (or what was accepted)
+	if !g.TrucoSequence.IsEmpty() {
+		mazo.TrucoPoints = g.TrucoSequence.Cost()
+	}
+	// If envido is finished, it either was played and its points are (or will be) awarded, or
+	// it "expired" after the first faceoff. Otherwise, it wasn't played but still could be.
+	if !g.IsEnvidoFinished {
+		mazo.EnvidoPoints, mazo.IsEnvidoClaimed = a.unplayedEnvidoPoints(*g)
+	}
+	// A claimed envido is won like any other, by showing the cards.
+	if mazo.IsEnvidoClaimed && !g.revealEnvidoCards(g.teamEnvidoPlayerID(g.rivalPlayerID())) {
+		return fmt.Errorf("couldn't reveal the claimed envido score due to a bug, this code should be unreachable")
+	}
 
+	cost := mazo.TrucoPoints + mazo.EnvidoPoints
+	g.RoundsLog[g.RoundNumber].Mazo = mazo
 	g.RoundsLog[g.RoundNumber].TrucoPoints = cost
-	g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = g.TurnOpponentPlayerID
-	g.Players[g.TurnOpponentPlayerID].Score += cost
+	g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = g.rivalPlayerID()
+	g.addPoints(g.rivalPlayerID(), cost, POINTS_REASON_MAZO)
 	g.IsRoundFinished = true
 	return nil
 }
 
+// unplayedEnvidoPoints returns the points for the envido that can't be played after going to
+// the mazo, according to Rules.Mazo, and whether the opponent claimed it. They only claim it if
+// their team's envido score is better, since they must show it.
+func (a ActionSayMeVoyAlMazo) unplayedEnvidoPoints(g GameState) (int, bool) {
+	switch g.Rules.Mazo {
+	case MAZO_MANO_BEFORE_FIRST_CARD:
+		if a.PlayerID == g.RoundTurnPlayerID && len(g.Players[a.PlayerID].Hand.Revealed) == 0 {
+			return 1, false
+		}
+		return 0, false
+	case MAZO_ENVIDO_CLAIMABLE:
+		var (
+			opponent      = g.rivalPlayerID()
+			theirScore    = g.teamEnvidoScore(opponent)
+			ourScore      = g.teamEnvidoScore(a.PlayerID)
+			isTheirBetter = theirScore > ourScore || (theirScore == ourScore && g.TeamOf(g.RoundTurnPlayerID) == g.TeamOf(opponent))
+		)
+		if !isTheirBetter {
+			return 0, false
+		}
+		seq := EnvidoSequence{Sequence: []string{SAY_ENVIDO, SAY_ENVIDO_QUIERO}}
+		cost, _ := seq.Cost(g.Rules, g.Players[opponent].Score, g.Players[a.PlayerID].Score, false)
+		return cost, true
+	default:
+		return 1, false
+	}
+}
+
 func (a ActionSayMeVoyAlMazo) GetPriority() int {
 	return 2
 }
@@ -73,3 +73,137 @@ func TestScoreAfterMeVoyAlMazo(t *testing.T) {
 		})
 	}
 }
+
+func TestMazoRules(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: BASTO}}},    // 3
+		{Unrevealed: []Card{{Number: 4, Suit: ESPADA}, {Number: 5, Suit: ESPADA}, {Number: 6, Suit: ORO}}}, // 29
+	}
+	tests := []struct {
+		name                string
+		rules               Rules
+		actions             []Action
+		expectedIsPossible  bool
+		expectedMazo        MazoLog
+		expectedTrucoPoints int
+	}{
+		{
+			name:                "envido point: before envido",
+			actions:             []Action{NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 1, EnvidoPoints: 1},
+			expectedTrucoPoints: 2,
+		},
+		{
+			name:                "envido point: with a truco",
+			actions:             []Action{NewActionSayTruco(0), NewActionSayTrucoQuiero(1), NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 2, EnvidoPoints: 1},
+			expectedTrucoPoints: 3,
+		},
+		{
+			name:                "envido point: after the envido",
+			actions:             []Action{NewActionSayEnvido(0), NewActionSayEnvidoNoQuiero(1), NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 1},
+			expectedTrucoPoints: 1,
+		},
+		{
+			name:                "mano before first card: mano",
+			rules:               Rules{Mazo: MAZO_MANO_BEFORE_FIRST_CARD},
+			actions:             []Action{NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 1, EnvidoPoints: 1},
+			expectedTrucoPoints: 2,
+		},
+		{
+			name:                "mano before first card: not mano",
+			rules:               Rules{Mazo: MAZO_MANO_BEFORE_FIRST_CARD},
+			actions:             []Action{NewActionRevealCard(Card{Number: 1, Suit: COPA}, 0), NewActionSayMeVoyAlMazo(1)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 1, TrucoPoints: 1},
+			expectedTrucoPoints: 1,
+		},
+		{
+			name:                "envido claimable: the opponent has a better envido",
+			rules:               Rules{Mazo: MAZO_ENVIDO_CLAIMABLE},
+			actions:             []Action{NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 1, EnvidoPoints: 2, IsEnvidoClaimed: true},
+			expectedTrucoPoints: 3,
+		},
+		{
+			name:                "envido claimable: the folder has a better envido",
+			rules:               Rules{Mazo: MAZO_ENVIDO_CLAIMABLE},
+			actions:             []Action{NewActionRevealCard(Card{Number: 1, Suit: COPA}, 0), NewActionSayMeVoyAlMazo(1)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 1, TrucoPoints: 1},
+			expectedTrucoPoints: 1,
+		},
+		{
+			name:               "forbidden during envido: the envido points weren't awarded",
+			rules:              Rules{IsMazoForbiddenDuringEnvido: true},
+			actions:            []Action{NewActionSayEnvido(0), NewActionSayEnvidoQuiero(1), NewActionSayEnvidoScore(0), NewActionSaySonMejores(1), NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible: false,
+		},
+		{
+			name:                "forbidden during envido: the envido wasn't accepted",
+			rules:               Rules{IsMazoForbiddenDuringEnvido: true},
+			actions:             []Action{NewActionSayEnvido(0), NewActionSayEnvidoNoQuiero(1), NewActionSayMeVoyAlMazo(0)},
+			expectedIsPossible:  true,
+			expectedMazo:        MazoLog{PlayerID: 0, TrucoPoints: 1},
+			expectedTrucoPoints: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameState := New(withDeck(newTestDeck(hands)), WithRules(tt.rules))
+			mazo := tt.actions[len(tt.actions)-1]
+			for _, action := range tt.actions[:len(tt.actions)-1] {
+				action.Enrich(*gameState)
+				require.NoError(t, gameState.RunAction(action))
+			}
+			require.Equal(t, tt.expectedIsPossible, mazo.IsPossible(*gameState))
+			if !tt.expectedIsPossible {
+				return
+			}
+
+			scoreBefore := gameState.Players[gameState.OpponentOf(mazo.GetPlayerID())].Score
+			require.NoError(t, gameState.RunAction(mazo))
+			roundLog := gameState.RoundsLog[1]
+			require.Equal(t, &tt.expectedMazo, roundLog.Mazo)
+			require.Equal(t, tt.expectedTrucoPoints, roundLog.TrucoPoints)
+			require.Equal(t, scoreBefore+tt.expectedTrucoPoints, gameState.Players[gameState.OpponentOf(mazo.GetPlayerID())].Score)
+		})
+	}
+}
+
+func TestClaimedEnvidoIsShown(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: BASTO}}},    // 3
+		{Unrevealed: []Card{{Number: 4, Suit: ESPADA}, {Number: 5, Suit: ESPADA}, {Number: 6, Suit: ORO}}}, // 29
+	}
+
+	t.Run("the opponent has a better envido", func(t *testing.T) {
+		gameState := New(withDeck(newTestDeck(hands)), WithRules(Rules{Mazo: MAZO_ENVIDO_CLAIMABLE}))
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+
+		// Player 1 shows the cards of their 29, so player 0 sees why they won the envido.
+		require.Equal(t, []Card{{Number: 4, Suit: ESPADA}, {Number: 5, Suit: ESPADA}}, gameState.Players[1].Hand.Revealed)
+		require.Equal(t, []Card{{Number: 4, Suit: ESPADA}, {Number: 5, Suit: ESPADA}}, gameState.ToClientGameState(0).TheirRevealedCards)
+		require.Equal(t, 3, gameState.Players[1].Score)
+	})
+
+	t.Run("the folder has a better envido", func(t *testing.T) {
+		gameState := New(withDeck(newTestDeck(hands)), WithRules(Rules{Mazo: MAZO_ENVIDO_CLAIMABLE}))
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Number: 1, Suit: COPA}, 0)))
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(1)))
+
+		// Player 0 doesn't claim the envido, so nothing else is shown, and only the truco is scored.
+		require.False(t, gameState.RoundsLog[1].Mazo.IsEnvidoClaimed)
+		require.Equal(t, []Card{{Number: 1, Suit: COPA}}, gameState.Players[0].Hand.Revealed)
+		require.Empty(t, gameState.Players[1].Hand.Revealed)
+		require.Equal(t, 1, gameState.Players[0].Score)
+	})
+}
@@ -31,17 +31,22 @@ func (a ActionRevealCard) IsPossible(g GameState) bool {
 
 func (a *ActionRevealCard) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	step := CardRevealSequenceStep{
 		card:     a.Card,
 		playerID: g.TurnPlayerID,
 	}
+	faceoffs := len(g.CardRevealSequence.BistepWinners)
 	g.CardRevealSequence.AddStep(step, *g)
 	err := g.Players[g.TurnPlayerID].Hand.RevealCard(a.Card)
 	if err != nil {
 		return err
 	}
+	g.emit(&EventCardPlayed{evt: evt{Type: EVENT_CARD_PLAYED}, PlayerID: g.TurnPlayerID, Card: a.Card})
+	for faceoff := faceoffs; faceoff < len(g.CardRevealSequence.BistepWinners); faceoff++ {
+		g.emit(&EventFaceoffWon{evt: evt{Type: EVENT_FACEOFF_WON}, Faceoff: faceoff, WinnerPlayerID: g.CardRevealSequence.BistepWinners[faceoff]})
+	}
 	if g.CardRevealSequence.IsFinished() {
 		g.IsRoundFinished = true
 
@@ -54,12 +59,17 @@ func (a *ActionRevealCard) Run(g *GameState) error {
 			score = g.TrucoSequence.Cost()
 		}
 
-		g.Players[g.CardRevealSequence.WinnerPlayerID()].Score += score
+		winnerPlayerID := g.CardRevealSequence.WinnerPlayerID()
+		if winnerPlayerID == -1 {
+			// All faceoffs were tied, so the round's first player wins
+			winnerPlayerID = g.RoundTurnPlayerID
+		}
+		g.addPoints(winnerPlayerID, score, POINTS_REASON_TRUCO)
 		g.RoundsLog[g.RoundNumber].TrucoPoints = score
-		g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = g.CardRevealSequence.WinnerPlayerID()
+		g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID = winnerPlayerID
 	}
-	// If both players have revealed a card, then envido cannot be played anymore
-	if !g.IsEnvidoFinished && len(g.Players[g.TurnPlayerID].Hand.Revealed) >= 1 && len(g.Players[g.TurnOpponentPlayerID].Hand.Revealed) >= 1 {
+	// If all players have revealed a card (or two, if the rules allow it), then envido cannot be played anymore
+	if !g.IsEnvidoFinished && len(g.CardRevealSequence.Steps) >= g.envidoCardRevealLimit() {
 		g.IsEnvidoFinished = true
 	}
 	// Revealing a card may cause the envido score to be revealed
@@ -79,6 +89,10 @@ func (a ActionRevealCard) YieldsTurn(g GameState) bool {
 	return g.CardRevealSequence.YieldsTurn(g)
 }
 
+func (a ActionRevealCard) nextTurnPlayerID(g GameState) int {
+	return g.CardRevealSequence.NextPlayerID(g)
+}
+
 func (a *ActionRevealCard) Enrich(g GameState) {
 	if g.canAwardEnvidoPoints(Hand{Revealed: append(g.Players[g.TurnPlayerID].Hand.Revealed, a.Card)}) {
 		a.EnMesa = true
@@ -16,9 +16,9 @@ func (a ActionSaySonBuenas) IsPossible(g GameState) bool {
 	var (
 		mano       = g.RoundTurnPlayerID
 		me         = g.TurnPlayerID
-		other      = g.TurnOpponentPlayerID
-		meScore    = g.Players[me].Hand.EnvidoScore()
-		otherScore = g.Players[other].Hand.EnvidoScore()
+		other      = g.rivalPlayerID()
+		meScore    = g.teamEnvidoScore(me)
+		otherScore = g.teamEnvidoScore(other)
 	)
 
 	// TODO: should I allow people to lose voluntarily?
@@ -26,7 +26,7 @@ func (a ActionSaySonBuenas) IsPossible(g GameState) bool {
 	if meScore > otherScore {
 		return false
 	}
-	if meScore == otherScore && mano == me {
+	if meScore == otherScore && g.TeamOf(mano) == g.TeamOf(me) {
 		return false
 	}
 
@@ -35,16 +35,19 @@ func (a ActionSaySonBuenas) IsPossible(g GameState) bool {
 
 func (a ActionSaySonBuenas) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.EnvidoSequence.AddStep(a.GetName())
-	cost, err := g.EnvidoSequence.Cost(g.RuleMaxPoints, g.Players[g.TurnPlayerID].Score, g.Players[g.TurnOpponentPlayerID].Score, false)
+	// The opponent wins the envido, so theirs is the winner's score
+	cost, err := g.EnvidoSequence.Cost(g.Rules, g.Players[g.rivalPlayerID()].Score, g.Players[g.TurnPlayerID].Score, false)
 	if err != nil {
 		return err
 	}
 	g.RoundsLog[g.RoundNumber].EnvidoPoints = cost
-	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.TurnOpponentPlayerID
+	// The winner is whoever has the best score in the opponent's team, since they must reveal it
+	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.teamEnvidoPlayerID(g.rivalPlayerID())
 	g.IsEnvidoFinished = true
+	g.emitEnvidoResolved()
 	g.tryAwardEnvidoPoints(a.PlayerID)
 	return nil
 }
@@ -54,6 +57,10 @@ func (a ActionSaySonBuenas) YieldsTurn(g GameState) bool {
 	return g.TurnPlayerID != g.EnvidoSequence.StartingPlayerID
 }
 
+func (a ActionSaySonBuenas) nextTurnPlayerID(g GameState) int {
+	return g.EnvidoSequence.StartingPlayerID
+}
+
 func (a ActionSaySonBuenas) GetPriority() int {
 	return 1
 }
@@ -15,9 +15,9 @@ func (a ActionSaySonMejores) IsPossible(g GameState) bool {
 	var (
 		mano       = g.RoundTurnPlayerID
 		me         = g.TurnPlayerID
-		other      = g.TurnOpponentPlayerID
-		meScore    = g.Players[me].Hand.EnvidoScore()
-		otherScore = g.Players[other].Hand.EnvidoScore()
+		other      = g.rivalPlayerID()
+		meScore    = g.teamEnvidoScore(me)
+		otherScore = g.teamEnvidoScore(other)
 	)
 
 	// TODO: should I allow people to lose voluntarily?
@@ -25,7 +25,7 @@ func (a ActionSaySonMejores) IsPossible(g GameState) bool {
 	if meScore < otherScore {
 		return false
 	}
-	if meScore == otherScore && mano != me {
+	if meScore == otherScore && g.TeamOf(mano) != g.TeamOf(me) {
 		return false
 	}
 
@@ -34,16 +34,18 @@ func (a ActionSaySonMejores) IsPossible(g GameState) bool {
 
 func (a ActionSaySonMejores) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.EnvidoSequence.AddStep(a.GetName())
-	cost, err := g.EnvidoSequence.Cost(g.RuleMaxPoints, g.Players[g.TurnPlayerID].Score, g.Players[g.TurnOpponentPlayerID].Score, false)
+	cost, err := g.EnvidoSequence.Cost(g.Rules, g.Players[g.TurnPlayerID].Score, g.Players[g.rivalPlayerID()].Score, false)
 	if err != nil {
 		return err
 	}
 	g.RoundsLog[g.RoundNumber].EnvidoPoints = cost
-	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.TurnPlayerID
+	// The winner is whoever has the best score in the team, since they must reveal it
+	g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID = g.teamEnvidoPlayerID(g.TurnPlayerID)
 	g.IsEnvidoFinished = true
+	g.emitEnvidoResolved()
 	g.tryAwardEnvidoPoints(a.PlayerID)
 	return nil
 }
@@ -51,12 +53,16 @@ func (a ActionSaySonMejores) Run(g *GameState) error {
 func (a ActionSaySonMejores) YieldsTurn(g GameState) bool {
 	// In son_buenas/son_mejores/no_quiero, the turn should go to whoever started the sequence
 	// Unless the game should end due to the points won by this action.
-	if g.Players[a.PlayerID].Score+g.RoundsLog[g.RoundNumber].EnvidoPoints >= g.RuleMaxPoints {
+	if g.Players[a.PlayerID].Score+g.RoundsLog[g.RoundNumber].EnvidoPoints >= g.Rules.MaxPoints {
 		return false
 	}
 	return g.TurnPlayerID != g.EnvidoSequence.StartingPlayerID
 }
 
+func (a ActionSaySonMejores) nextTurnPlayerID(g GameState) int {
+	return g.EnvidoSequence.StartingPlayerID
+}
+
 func (a *ActionSaySonMejores) Enrich(g GameState) {
 	a.Score = g.Players[a.PlayerID].Hand.EnvidoScore()
 }
@@ -152,6 +152,10 @@ func NewActionRevealFlorScore(playerID int) Action {
 	return &ActionRevealFlorScore{act: act{Name: REVEAL_FLOR_SCORE, PlayerID: playerID}}
 }
 
+func NewActionForfeit(playerID int) Action {
+	return &ActionForfeit{act: act{Name: FORFEIT, PlayerID: playerID}}
+}
+
 func (a ActionSaySonMejores) String() string {
 	return fmt.Sprintf("Player %v says %v son mejores", a.PlayerID, a.Score)
 }
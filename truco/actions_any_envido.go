@@ -54,7 +54,8 @@ func (a ActionRevealEnvidoScore) IsPossible(g GameState) bool {
 	if roundLog.EnvidoWinnerPlayerID != a.PlayerID {
 		return false
 	}
-	if !g.IsRoundFinished && g.Players[a.PlayerID].Score+roundLog.EnvidoPoints < g.RuleMaxPoints {
+	// Before the round is finished, the winner may only reveal it on their turn, to win the game.
+	if !g.IsRoundFinished && (a.PlayerID != g.TurnPlayerID || g.Players[a.PlayerID].Score+roundLog.EnvidoPoints < g.Rules.MaxPoints) {
 		return false
 	}
 	revealedHand := Hand{Revealed: g.Players[a.PlayerID].Hand.Revealed}
@@ -67,7 +68,7 @@ func (a ActionSayRealEnvido) Run(g *GameState) error  { return g.AnyEnvidoAction
 
 func (a ActionSayEnvidoScore) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	g.EnvidoSequence.AddStep(a.GetName())
 	return nil
@@ -75,21 +76,37 @@ func (a ActionSayEnvidoScore) Run(g *GameState) error {
 
 func (a ActionRevealEnvidoScore) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
-	// We need to reveal the least amount of cards such that the envido score is revealed.
+	if !g.revealEnvidoCards(a.PlayerID) {
+		// we tried all possible reveal combinations, so it should be impossible that we didn't find the right combination!
+		return fmt.Errorf("couldn't reveal envido score due to a bug, this code should be unreachable")
+	}
+	if !g.tryAwardEnvidoPoints(a.PlayerID) {
+		panic("couldn't award envido score after running reveal envido score action due to a bug, this code should be unreachable")
+	}
+	return nil
+}
+
+// revealEnvidoCards reveals the least amount of the player's cards that shows their envido score,
+// and returns false if it couldn't (which is a bug).
+func (g *GameState) revealEnvidoCards(playerID int) bool {
 	// Since we don't know which cards to reveal, let's try all possible reveal combinations.
 	//
 	// allPossibleReveals is a `map[unrevealed_len][]map[card_index]struct{}{}`
 	//
-	// Note: len(unrevealed) == 0 must be impossible if this line is reached
+	// Note: if there are no unrevealed cards, the envido score must already be revealed
 	_s := struct{}{}
 	allPossibleReveals := map[int][]map[int]struct{}{
 		1: {{0: _s}}, // i.e. if there's only one unrevealed card, only option is to reveal that card
 		2: {{0: _s}, {1: _s}, {0: _s, 1: _s}},
 		3: {{0: _s}, {1: _s}, {2: _s}, {0: _s, 1: _s}, {0: _s, 2: _s}, {1: _s, 2: _s}},
 	}
-	curPlayersHand := g.Players[a.PlayerID].Hand
+	curPlayersHand := g.Players[playerID].Hand
+	revealedHand := Hand{Revealed: curPlayersHand.Revealed}
+	if revealedHand.EnvidoScore() == curPlayersHand.EnvidoScore() {
+		return true
+	}
 
 	// for each possible combination of card reveals
 	for _, is := range allPossibleReveals[len(curPlayersHand.Unrevealed)] {
@@ -100,8 +117,11 @@ func (a ActionRevealEnvidoScore) Run(g *GameState) error {
 			candidateHand.displayUnrevealedCards = append(candidateHand.displayUnrevealedCards, DisplayCard{Number: card.Number, Suit: card.Suit})
 		}
 
-		// and reveal the additional cards of this combination
-		for i := range is {
+		// and reveal the additional cards of this combination (in hand order, so that the result is deterministic)
+		for i := range curPlayersHand.Unrevealed {
+			if _, ok := is[i]; !ok {
+				continue
+			}
 			candidateHand.Revealed = append(candidateHand.Revealed, curPlayersHand.Unrevealed[i])
 			candidateHand.displayUnrevealedCards[i].IsHole = true
 		}
@@ -118,15 +138,11 @@ func (a ActionRevealEnvidoScore) Run(g *GameState) error {
 				}
 			}
 			// replace hand with our satisfactory candidate hand
-			g.Players[a.PlayerID].Hand = &candidateHand
-			if !g.tryAwardEnvidoPoints(a.PlayerID) {
-				panic("couldn't award envido score after running reveal envido score action due to a bug, this code should be unreachable")
-			}
-			return nil
+			g.Players[playerID].Hand = &candidateHand
+			return true
 		}
 	}
-	// we tried all possible reveal combinations, so it should be impossible that we didn't find the right combination!
-	return fmt.Errorf("couldn't reveal envido score due to a bug, this code should be unreachable")
+	return false
 }
 
 func (a *ActionSayEnvido) Enrich(g GameState)      { g.AnyEnvidoActionTypeEnrich(a) }
@@ -134,6 +150,7 @@ func (a *ActionSayFaltaEnvido) Enrich(g GameState) { g.AnyEnvidoActionTypeEnrich
 func (a *ActionSayRealEnvido) Enrich(g GameState)  { g.AnyEnvidoActionTypeEnrich(a) }
 
 func (a *ActionSayEnvidoScore) Enrich(g GameState) {
+	// In team games, it's the player's own score, since their teammates' is hidden from them
 	a.Score = g.Players[a.PlayerID].Hand.EnvidoScore()
 }
 
@@ -163,29 +180,41 @@ func (g GameState) AnyEnvidoActionTypeIsPossible(a Action) bool {
 		return false
 	}
 	// If there was a "truco" and an answer to it, regardless when, envido is not possible anymore.
-	if len(g.TrucoSequence.Sequence) >= 2 {
+	// Before the answer, the player who must answer may say envido first ("el envido está primero").
+	if !g.TrucoSequence.IsEmpty() && !g.TrucoSequence.CanBeInterruptedByEnvido() {
+		return false
+	}
+	if g.TrucoSequence.IsPending() && g.EnvidoSequence.IsEmpty() && a.GetPlayerID() != g.TrucoSequence.AnsweringPlayerID {
 		return false
 	}
-	// If the initial two cards have been revealed, envido is finished
-	if len(g.CardRevealSequence.Steps) > 2 {
+	// If all players have revealed their first card, envido is finished
+	if len(g.CardRevealSequence.Steps) > g.envidoCardRevealLimit() {
 		return false
 	}
 	return g.EnvidoSequence.CanAddStep(a.GetName())
 }
 
+// envidoCardRevealLimit is the number of revealed cards after which envido can't be played.
+func (g GameState) envidoCardRevealLimit() int {
+	if g.Rules.IsEnvidoAllowedInSecondFaceoff {
+		return 2 * len(g.RoundPlayerIDs)
+	}
+	return len(g.RoundPlayerIDs)
+}
+
 func (g *GameState) AnyEnvidoActionTypeRunAction(a Action) error {
 	if g.IsEnvidoFinished {
-		return errEnvidoFinished
+		return ErrEnvidoFinished
 	}
 	if !g.AnyEnvidoActionTypeIsPossible(a) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	if g.EnvidoSequence.IsEmpty() {
 		g.EnvidoSequence.StartingPlayerID = g.TurnPlayerID
 	}
 	ok := g.EnvidoSequence.AddStep(a.GetName())
 	if !ok {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	return nil
 }
@@ -199,9 +228,9 @@ func (g GameState) AnyEnvidoActionTypeEnrich(a Action) {
 		youScore           = g.Players[a.GetPlayerID()].Score
 		theirScore         = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		quieroSeq, err     = seq.WithStep(SAY_ENVIDO_QUIERO)
-		quieroCost, err2   = quieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err2   = quieroSeq.Cost(g.Rules, youScore, theirScore, true)
 		noQuieroSeq, err3  = seq.WithStep(SAY_ENVIDO_NO_QUIERO)
-		noQuieroCost, err4 = noQuieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		noQuieroCost, err4 = noQuieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err != nil {
 		panic(err)
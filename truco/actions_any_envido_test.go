@@ -47,7 +47,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son mejores with 15 points",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 15
+				g.Rules.MaxPoints = 15
 			},
 			hands: []Hand{
 				{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}}, // 25
@@ -78,7 +78,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son mejores with 30 points (when starting)",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 30
+				g.Rules.MaxPoints = 30
 			},
 			hands: []Hand{
 				{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}}, // 25
@@ -112,7 +112,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son buenas with 15 points",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 15
+				g.Rules.MaxPoints = 15
 			},
 			hands: []Hand{
 				{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: ORO}}}, // 31
@@ -143,7 +143,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son buenas with 30 points (when starting)",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 30
+				g.Rules.MaxPoints = 30
 			},
 			hands: []Hand{
 				{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: ORO}}}, // 31
@@ -177,7 +177,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son mejores with 15 points, but winner is about to lose in 1 point",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 15
+				g.Rules.MaxPoints = 15
 				g.Players[0].Score = 14
 				g.Players[1].Score = 0
 			},
@@ -216,7 +216,7 @@ func TestEnvido(t *testing.T) {
 		{
 			name: "falta envido with son mejores with 30 points, loser has 14 points, but winner still wins 15 points because the game goes to 30",
 			changeInitialGameState: func(g *GameState) {
-				g.RuleMaxPoints = 30
+				g.Rules.MaxPoints = 30
 				g.Players[0].Score = 14
 				g.Players[1].Score = 0
 			},
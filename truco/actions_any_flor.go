@@ -93,7 +93,7 @@ func (a ActionSayFlorSonMejores) IsPossible(g GameState) bool {
 }
 
 func (a ActionRevealFlorScore) IsPossible(g GameState) bool {
-	if !g.RuleIsFlorEnabled {
+	if !g.Rules.IsFlorEnabled {
 		return false
 	}
 	if !g.Players[a.GetPlayerID()].Hand.HasFlor() {
@@ -106,14 +106,15 @@ func (a ActionRevealFlorScore) IsPossible(g GameState) bool {
 	if g.FlorSequence.FlorPointsAwarded {
 		return false
 	}
-	if !g.IsRoundFinished && g.Players[a.PlayerID].Score+roundLog.FlorPoints < g.RuleMaxPoints {
+	// Before the round is finished, the winner may only reveal it on their turn, to win the game.
+	if !g.IsRoundFinished && (a.PlayerID != g.TurnPlayerID || g.Players[a.PlayerID].Score+roundLog.FlorPoints < g.Rules.MaxPoints) {
 		return false
 	}
 	return len(g.Players[a.PlayerID].Hand.Revealed) != 3
 }
 
 func (g GameState) anyFlorActionIsPossible(a Action) bool {
-	if !g.RuleIsFlorEnabled {
+	if !g.Rules.IsFlorEnabled {
 		return false
 	}
 	if !g.Players[a.GetPlayerID()].Hand.HasFlor() {
@@ -122,6 +123,9 @@ func (g GameState) anyFlorActionIsPossible(a Action) bool {
 	if g.IsRoundFinished {
 		return false
 	}
+	if g.Rules.IsContraflorDisabled && (a.GetName() == SAY_CONTRAFLOR || a.GetName() == SAY_CONTRAFLOR_AL_RESTO) {
+		return false
+	}
 	// For any flor action except "say_flor" && "reveal_flor_score", both players must have flor
 	if a.GetName() != SAY_FLOR && !g.Players[g.OpponentOf(a.GetPlayerID())].Hand.HasFlor() {
 		return false
@@ -194,9 +198,9 @@ func (a ActionSayFlorSonMejores) Run(g *GameState) error {
 
 func (a ActionRevealFlorScore) Run(g *GameState) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
-	g.IsEnvidoFinished = true
+	g.finishEnvidoAfterFlor()
 	for len(g.Players[a.PlayerID].Hand.Unrevealed) > 0 {
 		_ = g.Players[a.PlayerID].Hand.RevealCard(g.Players[a.PlayerID].Hand.Unrevealed[0])
 	}
@@ -208,26 +212,34 @@ func (a ActionRevealFlorScore) Run(g *GameState) error {
 
 func (g *GameState) anyFlorActionRun(a Action) error {
 	if !a.IsPossible(*g) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
-	g.IsEnvidoFinished = true
+	g.finishEnvidoAfterFlor()
 	g.FlorSequence.AddStep(a.GetName())
 	return nil
 }
 
+// finishEnvidoAfterFlor finishes the envido because a flor was said, unless the rules keep it.
+func (g *GameState) finishEnvidoAfterFlor() {
+	if !g.Rules.IsEnvidoKeptAfterFlor {
+		g.IsEnvidoFinished = true
+	}
+}
+
 func finalizeFlorSequence(winnerPlayerID int, g *GameState) error {
-	cost, err := g.FlorSequence.Cost(g.RuleMaxPoints, g.Players[winnerPlayerID].Score, g.Players[g.OpponentOf(winnerPlayerID)].Score, false)
+	cost, err := g.FlorSequence.Cost(g.Rules, g.Players[winnerPlayerID].Score, g.Players[g.OpponentOf(winnerPlayerID)].Score, false)
 	if err != nil {
 		return err
 	}
 	g.RoundsLog[g.RoundNumber].FlorWinnerPlayerID = winnerPlayerID
 	g.RoundsLog[g.RoundNumber].FlorPoints = cost
+	g.emit(&EventFlorResolved{evt: evt{Type: EVENT_FLOR_RESOLVED}, WinnerPlayerID: winnerPlayerID, Points: cost, WasAccepted: g.FlorSequence.WasAccepted()})
 	_, _ = g.tryAwardFlorPoints()
 	return nil
 }
 
 func (g *GameState) canAwardFlorPoints() (bool, error) {
-	if !g.RuleIsFlorEnabled {
+	if !g.Rules.IsFlorEnabled {
 		return false, fmt.Errorf("flor is not enabled")
 	}
 	wonBy := g.RoundsLog[g.RoundNumber].FlorWinnerPlayerID
@@ -258,7 +270,7 @@ func (g *GameState) tryAwardFlorPoints() (bool, error) {
 	}
 	wonBy := g.RoundsLog[g.RoundNumber].FlorWinnerPlayerID
 	score := g.RoundsLog[g.RoundNumber].FlorPoints
-	g.Players[wonBy].Score += score
+	g.addPoints(wonBy, score, POINTS_REASON_FLOR)
 	g.FlorSequence.FlorPointsAwarded = true
 	return true, nil
 }
@@ -269,7 +281,7 @@ func (a *ActionSayFlor) Enrich(g GameState) {
 		youScore         = g.Players[a.GetPlayerID()].Score
 		theirScore       = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		quieroSeq, err   = seq.WithStep(SAY_CON_FLOR_QUIERO)
-		quieroCost, err2 = quieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err2 = quieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err0 != nil {
 		return
@@ -288,7 +300,7 @@ func (a *ActionSayContraflor) Enrich(g GameState) {
 		youScore         = g.Players[a.GetPlayerID()].Score
 		theirScore       = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		quieroSeq, err   = seq.WithStep(SAY_CON_FLOR_QUIERO)
-		quieroCost, err2 = quieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err2 = quieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err0 != nil {
 		return
@@ -307,7 +319,7 @@ func (a *ActionSayContraflorAlResto) Enrich(g GameState) {
 		youScore         = g.Players[a.GetPlayerID()].Score
 		theirScore       = g.Players[g.OpponentOf(a.GetPlayerID())].Score
 		quieroSeq, err   = seq.WithStep(SAY_CON_FLOR_QUIERO)
-		quieroCost, err2 = quieroSeq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err2 = quieroSeq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err0 != nil {
 		return
@@ -325,7 +337,7 @@ func (a *ActionSayConFlorMeAchico) Enrich(g GameState) {
 		seq, err0         = g.FlorSequence.WithStep(SAY_CON_FLOR_ME_ACHICO)
 		youScore          = g.Players[a.GetPlayerID()].Score
 		theirScore        = g.Players[g.OpponentOf(a.GetPlayerID())].Score
-		noQuieroCost, err = seq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		noQuieroCost, err = seq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err0 != nil {
 		return
@@ -340,7 +352,7 @@ func (a *ActionSayConFlorQuiero) Enrich(g GameState) {
 		seq, err0       = g.FlorSequence.WithStep(SAY_CON_FLOR_QUIERO)
 		youScore        = g.Players[a.GetPlayerID()].Score
 		theirScore      = g.Players[g.OpponentOf(a.GetPlayerID())].Score
-		quieroCost, err = seq.Cost(g.RuleMaxPoints, youScore, theirScore, true)
+		quieroCost, err = seq.Cost(g.Rules, youScore, theirScore, true)
 	)
 	if err0 != nil {
 		return
@@ -383,7 +395,7 @@ func (a ActionSayFlorSonBuenas) YieldsTurn(g GameState) bool {
 func (a ActionSayFlorSonMejores) YieldsTurn(g GameState) bool {
 	// In son_buenas/son_mejores/no_quiero, the turn should go to whoever started the sequence
 	// Unless the game should end due to the points won by this action.
-	if g.Players[a.PlayerID].Score+g.RoundsLog[g.RoundNumber].FlorPoints >= g.RuleMaxPoints {
+	if g.Players[a.PlayerID].Score+g.RoundsLog[g.RoundNumber].FlorPoints >= g.Rules.MaxPoints {
 		return false
 	}
 	return a.PlayerID != g.FlorSequence.StartingPlayerID
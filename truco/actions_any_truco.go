@@ -37,10 +37,10 @@ func (g GameState) AnyTrucoActionIsPossible(a Action) bool {
 	if !g.EnvidoSequence.IsEmpty() && !g.IsEnvidoFinished {
 		return false
 	}
-	// Only the player who said "quiero" last can raise the stakes, unless quiero hasn't been said yet
+	// Only the team who said "quiero" last can raise the stakes, unless quiero hasn't been said yet
 	if (a.GetName() == SAY_QUIERO_RETRUCO || a.GetName() == SAY_QUIERO_VALE_CUATRO) &&
-		g.TrucoSequence.QuieroOwnerPlayerID != a.GetPlayerID() &&
-		g.TrucoSequence.QuieroOwnerPlayerID != -1 {
+		g.TrucoSequence.QuieroOwnerPlayerID != -1 &&
+		g.TeamOf(g.TrucoSequence.QuieroOwnerPlayerID) != g.TeamOf(a.GetPlayerID()) {
 		return false
 	}
 	return g.TrucoSequence.CanAddStep(a.GetName())
@@ -48,11 +48,11 @@ func (g GameState) AnyTrucoActionIsPossible(a Action) bool {
 
 func (g *GameState) AnyTrucoActionRunAction(at Action) error {
 	if !g.AnyTrucoActionIsPossible(at) {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 	ok := g.TrucoSequence.AddStep(at.GetName())
 	if !ok {
-		return errActionNotPossible
+		return ErrActionNotPossible
 	}
 
 	// Possible actions are "truco", "quiero retruco" and "quiero vale cuatro", not "quiero"/"no quiero".
@@ -62,6 +62,7 @@ func (g *GameState) AnyTrucoActionRunAction(at Action) error {
 	if g.TrucoSequence.IsSubsequenceStart() {
 		g.TrucoSequence.StartingPlayerID = g.TurnPlayerID
 	}
+	g.TrucoSequence.AnsweringPlayerID = g.rivalPlayerID()
 
 	return nil
 }
@@ -106,3 +106,90 @@ func TestTrucoRequiresReminder(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvidoEstaPrimero(t *testing.T) {
+	noFlor := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}},   // 25
+		{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}}, // 5
+	}
+	bothHaveFlor := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: COPA}, {Number: 3, Suit: COPA}}}, // 26
+		{Unrevealed: []Card{{Number: 4, Suit: ORO}, {Number: 5, Suit: ORO}, {Number: 6, Suit: ORO}}},    // 35
+	}
+	trucoAnswers := []string{SAY_TRUCO_QUIERO, SAY_TRUCO_NO_QUIERO, SAY_QUIERO_RETRUCO}
+
+	tests := []struct {
+		name                          string
+		hands                         []Hand
+		actions                       []Action
+		expectedTurnPlayerID          int
+		expectedPossibleActionNames   []string
+		expectedImpossibleActionNames []string
+	}{
+		{
+			name:                        "envido interrupts the truco, which is answered after son buenas",
+			hands:                       noFlor,
+			actions:                     []Action{NewActionSayTruco(0), NewActionSayEnvido(1), NewActionSayEnvidoQuiero(0), NewActionSayEnvidoScore(0), NewActionSaySonBuenas(1)},
+			expectedTurnPlayerID:        1,
+			expectedPossibleActionNames: trucoAnswers,
+		},
+		{
+			name:                        "falta envido interrupts the truco, which is answered after no quiero",
+			hands:                       noFlor,
+			actions:                     []Action{NewActionSayTruco(0), NewActionSayRealEnvido(1), NewActionSayFaltaEnvido(0), NewActionSayEnvidoNoQuiero(1)},
+			expectedTurnPlayerID:        1,
+			expectedPossibleActionNames: trucoAnswers,
+		},
+		{
+			name:                        "flor interrupts the truco, which is answered after the flor is over",
+			hands:                       bothHaveFlor,
+			actions:                     []Action{NewActionSayTruco(0), NewActionSayFlor(1), NewActionSayConFlorQuiero(0), NewActionSayFlorScore(0), NewActionSayFlorSonMejores(1)},
+			expectedTurnPlayerID:        1,
+			expectedPossibleActionNames: trucoAnswers,
+		},
+		{
+			name:                          "the truco can't be answered until the envido is over",
+			hands:                         noFlor,
+			actions:                       []Action{NewActionSayTruco(0), NewActionSayEnvido(1), NewActionSayEnvidoQuiero(0)},
+			expectedTurnPlayerID:          0,
+			expectedPossibleActionNames:   []string{SAY_ENVIDO_SCORE},
+			expectedImpossibleActionNames: trucoAnswers,
+		},
+		{
+			name:                          "envido can't be said once the truco is answered",
+			hands:                         noFlor,
+			actions:                       []Action{NewActionSayTruco(0), NewActionSayQuieroRetruco(1)},
+			expectedTurnPlayerID:          0,
+			expectedImpossibleActionNames: []string{SAY_ENVIDO, SAY_REAL_ENVIDO, SAY_FALTA_ENVIDO},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameState := New(withDeck(newTestDeck(tt.hands)), WithFlorEnabled(true))
+			for i, action := range tt.actions {
+				action.Enrich(*gameState)
+				require.NoError(t, gameState.RunAction(action), "at action %v", i)
+			}
+			require.Equal(t, tt.expectedTurnPlayerID, gameState.TurnPlayerID)
+
+			actionNames := []string{}
+			for _, a := range gameState.CalculatePossibleActions() {
+				actionNames = append(actionNames, a.GetName())
+			}
+			for _, name := range tt.expectedPossibleActionNames {
+				assert.Contains(t, actionNames, name)
+			}
+			for _, name := range tt.expectedImpossibleActionNames {
+				assert.NotContains(t, actionNames, name)
+			}
+		})
+	}
+
+	t.Run("only the player who must answer the truco can say envido", func(t *testing.T) {
+		gameState := New(withDeck(newTestDeck(noFlor)))
+		require.NoError(t, gameState.RunAction(NewActionSayTruco(0)))
+		require.False(t, NewActionSayEnvido(0).IsPossible(*gameState))
+		require.True(t, NewActionSayEnvido(1).IsPossible(*gameState))
+	})
+}
@@ -1,15 +1,43 @@
 package truco
 
+import "encoding/json"
+
 type CardRevealSequenceStep struct {
 	card     Card
 	playerID int
 }
 
+type cardRevealSequenceStepJSON struct {
+	Card     Card `json:"card"`
+	PlayerID int  `json:"playerID"`
+}
+
+func (s CardRevealSequenceStep) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cardRevealSequenceStepJSON{Card: s.card, PlayerID: s.playerID})
+}
+
+func (s *CardRevealSequenceStep) UnmarshalJSON(bs []byte) error {
+	var sj cardRevealSequenceStepJSON
+	if err := json.Unmarshal(bs, &sj); err != nil {
+		return err
+	}
+	s.card = sj.Card
+	s.playerID = sj.PlayerID
+	return nil
+}
+
 type CardRevealSequence struct {
 	Steps         []CardRevealSequenceStep `json:"steps"`
 	BistepWinners []int                    `json:"bistepWinners"`
 }
 
+func (crs CardRevealSequence) Clone() *CardRevealSequence {
+	return &CardRevealSequence{
+		Steps:         _cloneSlice(crs.Steps),
+		BistepWinners: _cloneSlice(crs.BistepWinners),
+	}
+}
+
 func (crs CardRevealSequence) CanAddStep(step CardRevealSequenceStep, g GameState) bool {
 	// Sanity check: the action's player must be the current player
 	if g.TurnPlayerID != step.playerID {
@@ -23,54 +51,26 @@ func (crs CardRevealSequence) CanAddStep(step CardRevealSequenceStep, g GameStat
 	if crs.IsFinished() {
 		return false
 	}
-	switch len(crs.Steps) {
-	case 0: // Sanity check: if there are no steps, the first step must be from the rounds's first player
-		return step.playerID == g.RoundTurnPlayerID
-	case 1: // If there is one step, the second step must be from the round's second player
-		return step.playerID == g.OpponentOf((g.RoundTurnPlayerID))
-	case 2: // If there are two steps, the third step must be from the first faceoff winner, or round's first player if tied
-		if crs.BistepWinners[0] == -1 {
-			return step.playerID == g.RoundTurnPlayerID
-		}
-		return step.playerID == crs.BistepWinners[0]
-	case 3: // If there are 3 steps, the 4th step must be from the first faceoff winner's opponent, or round's second player if tied
-		if crs.BistepWinners[0] == -1 {
-			return step.playerID == g.OpponentOf((g.RoundTurnPlayerID))
-		}
-		return step.playerID == g.OpponentOf(crs.BistepWinners[0])
-	case 4:
-		// This can get tricky so let's outline the cases
-		var (
-			mano  = g.RoundTurnPlayerID
-			other = g.OpponentOf(g.RoundTurnPlayerID)
-			tie   = -1
-		)
-
-		nextPlayer := map[[2]int]int{
-			{tie, tie}:     mano,
-			{tie, mano}:    -1, // mano wins
-			{tie, other}:   -1, // other wins
-			{mano, tie}:    -1, // mano wins
-			{mano, mano}:   -1, // mano wins
-			{mano, other}:  other,
-			{other, tie}:   -1, // other wins
-			{other, mano}:  mano,
-			{other, other}: -1, // other wins
-		}[[2]int{crs.BistepWinners[0], crs.BistepWinners[1]}]
-
-		if nextPlayer == -1 {
-			return false
-		}
+	return step.playerID == crs.NextPlayerID(g)
+}
 
-		return step.playerID == nextPlayer
-	case 5:
-		// The last card can only be thrown by the opponent of whoever played the previous card
-		lastStep := crs.Steps[len(crs.Steps)-2]
-		return step.playerID != lastStep.playerID
+// NextPlayerID returns the player who must reveal the next card, or -1 if the sequence is finished.
+//
+// Each faceoff is started by the previous faceoff's winner, or by the round's first player if it's
+// the first faceoff or the previous one was tied. Then, the round's players reveal a card each, in turn order.
+func (crs CardRevealSequence) NextPlayerID(g GameState) int {
+	if crs.IsFinished() {
+		return -1
 	}
-
-	// if 6 cards were revealed, the sequence is finished (unreachable due to finishing)
-	return false
+	var (
+		playerCount = len(g.RoundPlayerIDs)
+		faceoff     = len(crs.Steps) / playerCount
+		first       = 0 // index in RoundPlayerIDs, which starts with mano
+	)
+	if faceoff > 0 && crs.BistepWinners[faceoff-1] != -1 {
+		first = g.roundPlayerIndex(crs.BistepWinners[faceoff-1])
+	}
+	return g.RoundPlayerIDs[(first+len(crs.Steps)%playerCount)%playerCount]
 }
 
 func (crs *CardRevealSequence) AddStep(step CardRevealSequenceStep, g GameState) bool {
@@ -78,46 +78,75 @@ func (crs *CardRevealSequence) AddStep(step CardRevealSequenceStep, g GameState)
 		return false
 	}
 	crs.Steps = append(crs.Steps, step)
+	playerCount := len(g.RoundPlayerIDs)
 
-	// Edge case: as de espadas may win the round on the 3rd step
-	if len(crs.Steps) == 3 && step.card == (Card{Suit: ESPADA, Number: 1}) && crs.BistepWinners[0] == step.playerID {
+	// Edge case: as de espadas may win the round on the first card of the second faceoff
+	if len(crs.Steps) == playerCount+1 && step.card == (Card{Suit: ESPADA, Number: 1}) && crs.BistepWinners[0] == step.playerID {
 		crs.BistepWinners = append(crs.BistepWinners, step.playerID)
 		return true
 	}
 
-	// If there are 2 steps, compare the cards and compute the winner (or tie)
-	if len(crs.Steps)%2 == 0 && len(crs.Steps) > 0 {
-		previousStep := crs.Steps[len(crs.Steps)-2]
-		comparisonResult := step.card.CompareTrucoScore(previousStep.card)
-		switch comparisonResult {
+	// If all players revealed a card in this faceoff, compute the winner (or tie)
+	if len(crs.Steps)%playerCount == 0 {
+		crs.BistepWinners = append(crs.BistepWinners, faceoffWinnerPlayerID(crs.Steps[len(crs.Steps)-playerCount:]))
+	}
+
+	return true
+}
+
+// faceoffWinnerPlayerID returns the player who revealed the highest card, or -1 if it's tied
+// between teams. If teammates tie, the first of them to reveal wins the faceoff for the team.
+func faceoffWinnerPlayerID(steps []CardRevealSequenceStep) int {
+	var (
+		winner = steps[0]
+		isTied = false
+	)
+	for _, step := range steps[1:] {
+		switch step.card.CompareTrucoScore(winner.card) {
 		case 1:
-			crs.BistepWinners = append(crs.BistepWinners, step.playerID)
-		case -1:
-			crs.BistepWinners = append(crs.BistepWinners, previousStep.playerID)
+			winner = step
+			isTied = false
 		case 0:
-			crs.BistepWinners = append(crs.BistepWinners, -1)
+			if teamOf(step.playerID) != teamOf(winner.playerID) {
+				isTied = true
+			}
 		}
 	}
+	if isTied {
+		return -1
+	}
+	return winner.playerID
+}
 
-	return true
+// bistepWinnerTeams returns the team that won each faceoff, or -1 if it was tied.
+func (crs CardRevealSequence) bistepWinnerTeams() []int {
+	teams := make([]int, len(crs.BistepWinners))
+	for i, winner := range crs.BistepWinners {
+		teams[i] = -1
+		if winner != -1 {
+			teams[i] = teamOf(winner)
+		}
+	}
+	return teams
 }
 
 func (crs CardRevealSequence) IsFinished() bool {
 	if len(crs.BistepWinners) < 2 {
 		return false
 	}
+	winners := crs.bistepWinnerTeams()
 	// If there are two finished faceoffs
-	if len(crs.BistepWinners) == 2 {
+	if len(winners) == 2 {
 		// If each one won one, not finished
-		if crs.BistepWinners[0] != crs.BistepWinners[1] && crs.BistepWinners[0] != -1 && crs.BistepWinners[1] != -1 {
+		if winners[0] != winners[1] && winners[0] != -1 && winners[1] != -1 {
 			return false
 		}
 		// If one of them won both, finished
-		if crs.BistepWinners[0] == crs.BistepWinners[1] && crs.BistepWinners[0] != -1 {
+		if winners[0] == winners[1] && winners[0] != -1 {
 			return true
 		}
 		// If both are tied, not finished
-		if crs.BistepWinners[0] == crs.BistepWinners[1] && crs.BistepWinners[0] == -1 {
+		if winners[0] == winners[1] && winners[0] == -1 {
 			return false
 		}
 	}
@@ -128,12 +157,9 @@ func (crs CardRevealSequence) IsFinished() bool {
 
 // NOTE: this must be called AFTER AddStep
 func (crs CardRevealSequence) YieldsTurn(g GameState) bool {
-	// If an even number of cards were revealed, and the last faceoff winner is the current player, the turn is NOT yielded
-	// because the winner gets to start the next faceoff
-	if len(crs.Steps)%2 == 0 && len(crs.Steps) > 0 && crs.BistepWinners[len(crs.BistepWinners)-1] == crs.Steps[len(crs.Steps)-1].playerID {
-		return false
-	}
-	return true
+	// If the last faceoff winner is the current player, the turn is NOT yielded because the
+	// winner gets to start the next faceoff
+	return crs.NextPlayerID(g) != crs.Steps[len(crs.Steps)-1].playerID
 }
 
 func (crs CardRevealSequence) WinnerPlayerID() int {
@@ -141,19 +167,22 @@ func (crs CardRevealSequence) WinnerPlayerID() int {
 		// Shouldn't be called if the sequence is not finished
 		return -1
 	}
-	winsByPlayer := map[int]int{}
-	for _, winner := range crs.BistepWinners {
-		if winner == -1 {
+	winsByTeam := map[int]int{}
+	for _, team := range crs.bistepWinnerTeams() {
+		if team == -1 {
 			continue
 		}
-		winsByPlayer[winner]++
+		winsByTeam[team]++
 	}
+	// If both teams won the same number of faceoffs (i.e. the third one was tied), whoever
+	// won first wins. So, iterate in faceoff order, only replacing the winner on more wins.
+	// The winner is the team's first player to win a faceoff.
 	winningPlayerID := -1
 	mostWins := 0
-	for playerID, wins := range winsByPlayer {
-		if wins > mostWins {
+	for _, playerID := range crs.BistepWinners {
+		if playerID != -1 && winsByTeam[teamOf(playerID)] > mostWins {
 			winningPlayerID = playerID
-			mostWins = wins
+			mostWins = winsByTeam[teamOf(playerID)]
 		}
 	}
 	return winningPlayerID
@@ -1,6 +1,7 @@
 package truco
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -61,6 +62,34 @@ type Hand struct {
 	displayUnrevealedCards []DisplayCard
 }
 
+// handJSON is the JSON representation of a Hand. It includes the display cards, which are
+// private because clients should use ClientGameState's display cards instead, but are still
+// needed to restore a GameState.
+type handJSON struct {
+	Unrevealed             []Card        `json:"unrevealed"`
+	Revealed               []Card        `json:"revealed"`
+	DisplayUnrevealedCards []DisplayCard `json:"displayUnrevealedCards,omitempty"`
+}
+
+func (h Hand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(handJSON{
+		Unrevealed:             h.Unrevealed,
+		Revealed:               h.Revealed,
+		DisplayUnrevealedCards: h.displayUnrevealedCards,
+	})
+}
+
+func (h *Hand) UnmarshalJSON(bs []byte) error {
+	var hj handJSON
+	if err := json.Unmarshal(bs, &hj); err != nil {
+		return err
+	}
+	h.Unrevealed = hj.Unrevealed
+	h.Revealed = hj.Revealed
+	h.displayUnrevealedCards = hj.DisplayUnrevealedCards
+	return nil
+}
+
 func (h Hand) DeepCopy() Hand {
 	cpyUnrevealed := []Card{}
 	cpyRevealed := []Card{}
@@ -73,8 +102,18 @@ func (h Hand) DeepCopy() Hand {
 		cpyRevealed = append(cpyRevealed, newC)
 	}
 	return Hand{
-		Unrevealed: cpyUnrevealed,
-		Revealed:   cpyRevealed,
+		Unrevealed:             cpyUnrevealed,
+		Revealed:               cpyRevealed,
+		displayUnrevealedCards: _cloneSlice(h.displayUnrevealedCards),
+	}
+}
+
+// clone is like DeepCopy, but it keeps nil slices as nil, so that the clone serializes the same.
+func (h Hand) clone() *Hand {
+	return &Hand{
+		Unrevealed:             _cloneSlice(h.Unrevealed),
+		Revealed:               _cloneSlice(h.Revealed),
+		displayUnrevealedCards: _cloneSlice(h.displayUnrevealedCards),
 	}
 }
 
@@ -159,7 +198,7 @@ var (
 	errCardAlreadyRevealed = errors.New("card already revealed")
 )
 
-func makeSpanishCards() []Card {
+func makeSpanishCards(r *rand.Rand) []Card {
 	cards := []Card{}
 	suits := []string{ORO, COPA, ESPADA, BASTO}
 	for _, suit := range suits {
@@ -171,7 +210,7 @@ func makeSpanishCards() []Card {
 		}
 	}
 
-	rand.Shuffle(len(cards), func(i, j int) {
+	r.Shuffle(len(cards), func(i, j int) {
 		cards[i], cards[j] = cards[j], cards[i]
 	})
 
@@ -179,13 +218,13 @@ func makeSpanishCards() []Card {
 }
 
 func newDeck() *deck {
-	d := deck{cards: makeSpanishCards()}
+	d := deck{}
 	d.dealHandFunc = d.defaultDealHand
 	return &d
 }
 
-func (d *deck) shuffle() {
-	d.cards = makeSpanishCards()
+func (d *deck) shuffle(r *rand.Rand) {
+	d.cards = makeSpanishCards(r)
 }
 
 func (d *deck) dealHand() *Hand {
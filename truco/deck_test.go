@@ -2,9 +2,11 @@ package truco
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCard_CompareTrucoScore(t *testing.T) {
@@ -114,3 +116,52 @@ func TestEnvidoScore(t *testing.T) {
 		})
 	}
 }
+
+func TestSameSeedDealsSameCards(t *testing.T) {
+	var (
+		a     = New(WithSeed(42))
+		b     = New(WithSeed(42))
+		other = New(WithSeed(43))
+	)
+	require.Equal(t, int64(42), a.Seed)
+	require.Equal(t, a.Players[0].Hand, b.Players[0].Hand)
+	require.Equal(t, a.Players[1].Hand, b.Players[1].Hand)
+	require.NotEqual(t, a.RoundsLog[1].HandsDealt, other.RoundsLog[1].HandsDealt)
+
+	// Every round is dealt the same cards, as long as the same actions are played.
+	for _, gameState := range []*GameState{a, b} {
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+		require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(1)))
+		require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(0)))
+	}
+	require.Equal(t, 2, a.RoundNumber)
+	require.Equal(t, a.RoundsLog[2].HandsDealt, b.RoundsLog[2].HandsDealt)
+	require.NotEqual(t, a.RoundsLog[1].HandsDealt, a.RoundsLog[2].HandsDealt)
+}
+
+func TestWithRandSource(t *testing.T) {
+	a := New(WithRandSource(rand.NewSource(1)))
+	b := New(WithRandSource(rand.NewSource(1)))
+	require.Equal(t, a.RoundsLog[1].HandsDealt, b.RoundsLog[1].HandsDealt)
+}
+
+func TestWithDealer(t *testing.T) {
+	hands := map[int]Hand{
+		0: {Unrevealed: []Card{{Suit: ESPADA, Number: 1}, {Suit: ESPADA, Number: 7}, {Suit: ORO, Number: 7}}},
+		1: {Unrevealed: []Card{{Suit: COPA, Number: 4}, {Suit: COPA, Number: 5}, {Suit: COPA, Number: 6}}},
+	}
+	dealtRounds := []int{}
+	gameState := New(WithDealer(func(roundNumber int, playerID int) Hand {
+		dealtRounds = append(dealtRounds, roundNumber)
+		return hands[playerID]
+	}))
+
+	require.Equal(t, hands[0].Unrevealed, gameState.Players[0].Hand.Unrevealed)
+	require.Equal(t, hands[1].Unrevealed, gameState.Players[1].Hand.Unrevealed)
+	require.Equal(t, []int{1, 1}, dealtRounds)
+	require.Len(t, gameState.ToClientGameState(0).YourDisplayUnrevealedCards, 3)
+
+	// Revealing a card doesn't change the dealer's hands.
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: ESPADA, Number: 1}, 0)))
+	require.Len(t, hands[0].Unrevealed, 3)
+}
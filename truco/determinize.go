@@ -0,0 +1,175 @@
+package truco
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrHandsNotConsistent is returned by Determinize when the given hands contradict what the
+// player saw, e.g. an opponent said flor but their hand doesn't have one.
+var ErrHandsNotConsistent = errors.New("hands are not consistent with the game")
+
+var errDeterminizeNoHistory = errors.New("client game state has no history of the current round")
+
+// Determinize returns a GameState that is consistent with what a player sees in a ClientGameState,
+// guessing the cards they can't see, e.g. so that a bot can simulate how the game could go on.
+//
+// hands has the hands dealt on the current round to every player but you (in a pica-pica round,
+// the hands dealt on its first duel), with all their cards. Your hand is taken from the history.
+// Pass options to shuffle the next rounds (e.g. WithRandSource); the game's rules are always the
+// client's.
+//
+// The current round is replayed from its start with the given hands, so ErrHandsNotConsistent is
+// returned if the hands can't explain it, e.g. a player's envido score isn't the one they said, or
+// they revealed a card that isn't in their hand.
+//
+// The players who confirmed the end of a finished round aren't known, so nobody confirmed it in
+// the returned GameState.
+func Determinize(cgs ClientGameState, hands map[int]Hand, opts ...func(*GameState)) (*GameState, error) {
+	if cgs.RoundNumber < 1 || len(cgs.History) != cgs.RoundNumber {
+		return nil, errDeterminizeNoHistory
+	}
+	var (
+		current         = cgs.History[cgs.RoundNumber-1]
+		dealRoundNumber = current.RoundNumber - current.PicaPicaDuel
+		g               = New(append([]func(*GameState){WithPlayerCount(len(cgs.Players)), WithRules(cgs.Rules)}, opts...)...)
+	)
+
+	// Go back to the end of the round before the cards were dealt.
+	g.RoundNumber = dealRoundNumber - 1
+	g.RoundsLog = []*RoundLog{{}}
+	for _, clientRoundLog := range cgs.History[:dealRoundNumber-1] {
+		g.RoundsLog = append(g.RoundsLog, clientRoundLog.roundLog(cgs.YouPlayerID))
+	}
+	g.RoundTurnPlayerID, g.RoundType, g.PicaPicaDuel, g.RoundPlayerIDs = -1, "", 0, nil
+	if dealRoundNumber > 1 {
+		previous := cgs.History[dealRoundNumber-2]
+		g.RoundTurnPlayerID, g.RoundType, g.PicaPicaDuel = previous.RoundTurnPlayerID, previous.Type, previous.PicaPicaDuel
+		g.RoundPlayerIDs = _cloneSlice(previous.PlayerIDs)
+	}
+	for playerID, player := range g.Players {
+		player.Score = cgs.Players[playerID].Score
+		for _, clientRoundLog := range cgs.History[dealRoundNumber-1:] {
+			player.Score -= clientRoundLog.TeamPoints[g.TeamOf(playerID)]
+		}
+		// The previous round's revealed cards are logged when the next round starts.
+		player.Hand = &Hand{}
+		if dealRoundNumber > 1 {
+			player.Hand.Revealed = _cloneSlice(cgs.History[dealRoundNumber-2].RevealedCards[playerID])
+		}
+	}
+	g.updateStages()
+
+	yourHand := Hand{Unrevealed: cgs.History[dealRoundNumber-1].YourHandDealt}
+	g.dealer = func(_ int, playerID int) Hand {
+		if playerID == cgs.YouPlayerID {
+			return yourHand
+		}
+		// Like in Replay, revealed cards are put back in the hand.
+		return Hand{Unrevealed: append(_cloneSlice(hands[playerID].Unrevealed), hands[playerID].Revealed...)}
+	}
+	g.startNewRound()
+	g.dealer = nil
+
+	// Replay the round (in a pica-pica round, every duel so far), checking the hands explain it.
+	for roundNumber := dealRoundNumber; ; roundNumber++ {
+		if g.RoundTurnPlayerID != cgs.History[roundNumber-1].RoundTurnPlayerID {
+			return nil, fmt.Errorf("%w: round %v has a different mano", ErrHandsNotConsistent, roundNumber)
+		}
+		for i, actionLog := range cgs.History[roundNumber-1].ActionsLog {
+			if err := g.runLoggedAction(actionLog); err != nil {
+				return nil, fmt.Errorf("%w: round %v action %v: %v", ErrHandsNotConsistent, roundNumber, i, err)
+			}
+		}
+		// Whether the envido was claimed on a mazo depends on the hands too.
+		if mazo := cgs.History[roundNumber-1].Mazo; mazo != nil && *mazo != *g.RoundsLog[roundNumber].Mazo {
+			return nil, fmt.Errorf("%w: round %v has a different mazo", ErrHandsNotConsistent, roundNumber)
+		}
+		if roundNumber == cgs.RoundNumber {
+			break
+		}
+		for playerID := 0; playerID < len(g.Players); playerID++ {
+			if err := g.RunAction(NewActionConfirmRoundFinished(playerID)); err != nil {
+				return nil, fmt.Errorf("%w: round %v confirming round finished: %v", ErrHandsNotConsistent, roundNumber, err)
+			}
+		}
+	}
+	if !g.IsRoundFinished && g.TurnPlayerID != cgs.TurnPlayerID {
+		return nil, fmt.Errorf("%w: it's not player %v's turn", ErrHandsNotConsistent, cgs.TurnPlayerID)
+	}
+	g.Events = nil
+	return g, nil
+}
+
+// runLoggedAction runs an action of the current round's log, checking that what the player said
+// about their hand (e.g. their envido score) is what their hand in this game state says.
+func (g *GameState) runLoggedAction(actionLog ActionLog) error {
+	action, err := DeserializeAction(actionLog.Action)
+	if err != nil {
+		return err
+	}
+	if action.GetPlayerID() != g.TurnPlayerID {
+		return ErrNotYourTurn
+	}
+	if revealsHand(action) {
+		enriched, _ := DeserializeAction(actionLog.Action)
+		enriched.Enrich(*g)
+		if !jsonEqual(SerializeAction(enriched), actionLog.Action) {
+			return fmt.Errorf("[%v] doesn't match the player's hand", action)
+		}
+	}
+	return g.RunAction(action)
+}
+
+// revealsHand returns whether the action tells something about the player's hand when it's
+// enriched, i.e. an envido or flor score.
+func revealsHand(action Action) bool {
+	switch action.GetName() {
+	case REVEAL_CARD, SAY_ENVIDO_SCORE, SAY_SON_MEJORES, REVEAL_ENVIDO_SCORE, SAY_FLOR_SCORE, SAY_FLOR_SON_MEJORES, REVEAL_FLOR_SCORE:
+		return true
+	}
+	return false
+}
+
+// jsonEqual returns whether two JSON documents are equal regardless of whitespace and key order.
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ba, _ := json.Marshal(va)
+	bb, _ := json.Marshal(vb)
+	return string(ba) == string(bb)
+}
+
+// roundLog returns the log of a past round, with what the player saw of it.
+func (crl ClientRoundLog) roundLog(youPlayerID int) *RoundLog {
+	roundLog := &RoundLog{
+		HandsDealt:           map[int]*Hand{},
+		Type:                 crl.Type,
+		PicaPicaDuel:         crl.PicaPicaDuel,
+		PlayerIDs:            _cloneSlice(crl.PlayerIDs),
+		TeamPoints:           crl.TeamPoints,
+		FlorWinnerPlayerID:   crl.FlorWinnerPlayerID,
+		FlorPoints:           crl.FlorPoints,
+		EnvidoWinnerPlayerID: crl.EnvidoWinnerPlayerID,
+		EnvidoPoints:         crl.EnvidoPoints,
+		TrucoWinnerPlayerID:  crl.TrucoWinnerPlayerID,
+		TrucoPoints:          crl.TrucoPoints,
+		ActionsLog:           _cloneSlice(crl.ActionsLog),
+		ScoresLog:            _cloneSlice(crl.ScoresLog),
+		RevealedCards:        map[int][]Card{},
+	}
+	if crl.Mazo != nil {
+		mazo := *crl.Mazo
+		roundLog.Mazo = &mazo
+	}
+	if crl.YourHandDealt != nil {
+		roundLog.HandsDealt[youPlayerID] = &Hand{Unrevealed: _cloneSlice(crl.YourHandDealt)}
+	}
+	for playerID, cards := range crl.RevealedCards {
+		roundLog.RevealedCards[playerID] = _cloneSlice(cards)
+	}
+	return roundLog
+}
@@ -0,0 +1,101 @@
+package truco
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeterminizeWithTheDealtHands plays random games of every kind, and at every step it checks
+// that determinizing each player's ClientGameState with the hands that were actually dealt gives
+// a game state that looks exactly the same to the player.
+func TestDeterminizeWithTheDealtHands(t *testing.T) {
+	configs := []struct {
+		name string
+		opts []func(*GameState)
+	}{
+		{name: "two players", opts: []func(*GameState){WithMaxPoints(15)}},
+		{name: "two players with flor", opts: []func(*GameState){WithMaxPoints(15), WithFlorEnabled(true)}},
+		{name: "four players", opts: []func(*GameState){WithMaxPoints(15), WithPlayerCount(4)}},
+		{name: "six players with pica-pica", opts: []func(*GameState){WithPlayerCount(6), WithPicaPica(0, 30)}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				var (
+					r         = rand.New(rand.NewSource(int64(i)))
+					gameState = New(append(config.opts, WithSeed(int64(i)))...)
+				)
+				for step := 0; !gameState.IsGameEnded; step++ {
+					// Nobody confirmed the end of the round in a determinized game state.
+					if len(gameState.RoundFinishedConfirmedPlayerIDs) == 0 {
+						for playerID := range gameState.Players {
+							requireDeterminizedLooksTheSame(t, gameState, playerID, fmt.Sprintf("game %v step %v player %v", i, step, playerID))
+						}
+					}
+					actions := gameState.CalculatePossibleActions()
+					err := gameState.RunAction(actions[r.Intn(len(actions))])
+					if errors.Is(err, ErrNotYourTurn) {
+						continue
+					}
+					require.NoError(t, err, "game %v step %v", i, step)
+				}
+			}
+		})
+	}
+}
+
+func requireDeterminizedLooksTheSame(t *testing.T, gameState *GameState, playerID int, msg string) {
+	clientGameState := gameState.ToClientGameState(playerID)
+	dealRoundNumber := gameState.RoundNumber - gameState.PicaPicaDuel
+	hands := map[int]Hand{}
+	for otherPlayerID, hand := range gameState.RoundsLog[dealRoundNumber].HandsDealt {
+		if otherPlayerID != playerID {
+			hands[otherPlayerID] = *hand
+		}
+	}
+
+	determinized, err := Determinize(clientGameState, hands)
+	require.NoError(t, err, msg)
+	determinizedClientGameState := determinized.ToClientGameState(playerID)
+	clientGameState.Events, determinizedClientGameState.Events = nil, nil
+	require.Equal(t, clientGameState, determinizedClientGameState, msg)
+	require.Equal(t, gameState.CalculatePossibleActions(), determinized.CalculatePossibleActions(), msg)
+}
+
+func TestDeterminizeRejectsInconsistentHands(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}},   // 25
+		{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}}, // 5
+	}
+	gameState := New(withDeck(newTestDeck(hands)))
+	actions := []Action{
+		NewActionSayEnvido(0),
+		NewActionSayEnvidoQuiero(1),
+		NewActionSayEnvidoScore(0),
+		NewActionSaySonBuenas(1),
+		NewActionRevealCard(Card{Number: 1, Suit: COPA}, 0),
+	}
+	for i, action := range actions {
+		action.Enrich(*gameState)
+		require.NoError(t, gameState.RunAction(action), "at action %v", i)
+	}
+	clientGameState := gameState.ToClientGameState(1)
+
+	_, err := Determinize(clientGameState, map[int]Hand{0: hands[0]})
+	require.NoError(t, err)
+
+	// Player 0 said 25 of envido.
+	_, err = Determinize(clientGameState, map[int]Hand{0: {Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 7, Suit: ORO}, {Number: 3, Suit: ESPADA}}}})
+	require.ErrorIs(t, err, ErrHandsNotConsistent)
+
+	// Player 0 revealed the 1 de copa.
+	_, err = Determinize(clientGameState, map[int]Hand{0: {Unrevealed: []Card{{Number: 1, Suit: BASTO}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}}})
+	require.ErrorIs(t, err, ErrHandsNotConsistent)
+
+	_, err = Determinize(ClientGameState{}, map[int]Hand{})
+	require.Error(t, err)
+}
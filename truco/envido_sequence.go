@@ -88,9 +88,46 @@ var (
 	}
 )
 
+// validRepeatedRealEnvidoSequenceCosts are the sequences in which real envido is repeated, which
+// are only valid with Rules.IsRealEnvidoRepeatable. Their costs follow the same logic as the rest.
+var validRepeatedRealEnvidoSequenceCosts = func() map[string]int {
+	costs := map[string]int{}
+	for _, prefix := range []struct {
+		steps        []string
+		quieroCost   int
+		noQuieroCost int
+	}{
+		{[]string{SAY_REAL_ENVIDO, SAY_REAL_ENVIDO}, 6, 3},
+		{[]string{SAY_ENVIDO, SAY_REAL_ENVIDO, SAY_REAL_ENVIDO}, 8, 5},
+		{[]string{SAY_ENVIDO, SAY_ENVIDO, SAY_REAL_ENVIDO, SAY_REAL_ENVIDO}, 10, 7},
+	} {
+		steps := func(more ...string) string { return _s(append(_cloneSlice(prefix.steps), more...)...) }
+		costs[steps()] = COST_NOT_READY
+		costs[steps(SAY_ENVIDO_NO_QUIERO)] = prefix.noQuieroCost
+		costs[steps(SAY_FALTA_ENVIDO)] = COST_NOT_READY
+		costs[steps(SAY_FALTA_ENVIDO, SAY_ENVIDO_NO_QUIERO)] = prefix.quieroCost
+		for _, quiero := range []struct {
+			steps []string
+			cost  int
+		}{
+			{[]string{SAY_ENVIDO_QUIERO}, prefix.quieroCost},
+			{[]string{SAY_FALTA_ENVIDO, SAY_ENVIDO_QUIERO}, COST_FALTA_ENVIDO},
+		} {
+			costs[steps(quiero.steps...)] = quiero.cost
+			costs[steps(append(quiero.steps, SAY_ENVIDO_SCORE)...)] = quiero.cost
+			costs[steps(append(quiero.steps, SAY_ENVIDO_SCORE, SAY_SON_BUENAS)...)] = quiero.cost
+			costs[steps(append(quiero.steps, SAY_ENVIDO_SCORE, SAY_SON_MEJORES)...)] = quiero.cost
+		}
+	}
+	return costs
+}()
+
 type EnvidoSequence struct {
 	Sequence []string `json:"sequence"`
 
+	// IsRealEnvidoRepeatable is Rules.IsRealEnvidoRepeatable, which changes what sequences are valid.
+	IsRealEnvidoRepeatable bool `json:"isRealEnvidoRepeatable,omitempty"`
+
 	// This is necessary because when son_buenas/son_mejores/no_quiero is said,
 	// the turn goes to whoever started the envido sequence (i.e. affects YieldsTurn)
 	StartingPlayerID int `json:"startingPlayerID"`
@@ -112,10 +149,21 @@ func (es EnvidoSequence) IsEmpty() bool {
 }
 
 func (es EnvidoSequence) isValid() bool {
-	_, ok := validEnvidoSequenceCosts[es.String()]
+	_, ok := es.cost()
 	return ok
 }
 
+func (es EnvidoSequence) cost() (int, bool) {
+	if cost, ok := validEnvidoSequenceCosts[es.String()]; ok {
+		return cost, true
+	}
+	if es.IsRealEnvidoRepeatable {
+		cost, ok := validRepeatedRealEnvidoSequenceCosts[es.String()]
+		return cost, ok
+	}
+	return COST_NOT_READY, false
+}
+
 func (es *EnvidoSequence) CanAddStep(step string) bool {
 	es.Sequence = append(es.Sequence, step)
 	isValid := es.isValid()
@@ -139,13 +187,13 @@ func (es *EnvidoSequence) IsFinished() bool {
 	return last == SAY_SON_BUENAS || last == SAY_SON_MEJORES || last == SAY_ENVIDO_NO_QUIERO
 }
 
-func (es EnvidoSequence) Cost(maxPoints, winnerPlayerScore, loserPlayerScore int, forHint bool) (int, error) {
-	if !es.isValid() {
+func (es EnvidoSequence) Cost(rules Rules, winnerPlayerScore, loserPlayerScore int, forHint bool) (int, error) {
+	cost, ok := es.cost()
+	if !ok {
 		return COST_NOT_READY, errInvalidEnvidoSequence
 	}
-	cost := validEnvidoSequenceCosts[es.String()]
 	if cost == COST_FALTA_ENVIDO {
-		return calculateFaltaEnvidoCost(maxPoints, winnerPlayerScore, loserPlayerScore), nil
+		return calculateFaltaEnvidoCost(rules, winnerPlayerScore, loserPlayerScore), nil
 	}
 	// If this is a hint, return the cost as is, without checking if the sequence is finished.
 	if forHint {
@@ -168,9 +216,10 @@ func (es EnvidoSequence) WasAccepted() bool {
 
 func (es EnvidoSequence) Clone() *EnvidoSequence {
 	return &EnvidoSequence{
-		Sequence:            append([]string{}, es.Sequence...),
-		StartingPlayerID:    es.StartingPlayerID,
-		EnvidoPointsAwarded: es.EnvidoPointsAwarded,
+		Sequence:               _cloneSlice(es.Sequence),
+		IsRealEnvidoRepeatable: es.IsRealEnvidoRepeatable,
+		StartingPlayerID:       es.StartingPlayerID,
+		EnvidoPointsAwarded:    es.EnvidoPointsAwarded,
 	}
 }
 
@@ -183,24 +232,22 @@ func (es EnvidoSequence) WithStep(step string) (EnvidoSequence, error) {
 	return *newEs, nil
 }
 
-func _calculateFaltaEnvidoCost15PointsStrategy(maxPoints, winnerScore, loserScore int) int {
-	return maxPoints - max(winnerScore, loserScore)
-}
-
-func _calculateFaltaEnvidoCost30PointsStrategy(maxPoints, winnerScore, loserScore int) int {
-	if winnerScore < 15 && loserScore < 15 {
-		return 15 - winnerScore
+func calculateFaltaEnvidoCost(rules Rules, winnerScore, loserScore int) int {
+	leaderScore := max(winnerScore, loserScore)
+	// In buenas, it's always the points the leader needs to win. Note that games of 15 points
+	// or less have no malas, so they are always in buenas.
+	if rules.StageOf(leaderScore) == STAGE_BUENAS {
+		return rules.maxPoints() - leaderScore
 	}
-	return maxPoints - max(winnerScore, loserScore)
-}
-
-func calculateFaltaEnvidoCost(maxPoints, winnerScore, loserScore int) int {
-	// maxPoints is normally only 15 or 30, but if it's set to less then
-	// use the same rule as for 15, but using maxPoints instead.
-	if maxPoints <= 15 {
-		return _calculateFaltaEnvidoCost15PointsStrategy(maxPoints, winnerScore, loserScore)
+	// Otherwise both teams are in malas
+	switch rules.FaltaEnvido {
+	case FALTA_ENVIDO_POINTS_TO_WIN:
+		return rules.maxPoints() - leaderScore
+	case FALTA_ENVIDO_WINS_GAME_IN_MALAS:
+		return rules.maxPoints() - winnerScore
+	default:
+		return rules.MalasPoints() - winnerScore
 	}
-	return _calculateFaltaEnvidoCost30PointsStrategy(maxPoints, winnerScore, loserScore)
 }
 
 var (
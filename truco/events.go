@@ -0,0 +1,163 @@
+package truco
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Event is something that happened in the game while running an action, e.g. a card was played
+// or a team was awarded points. GameState.RunAction leaves the events of the action it ran in
+// GameState.Events, so that clients can animate transitions without diffing consecutive states.
+type Event interface {
+	GetType() string
+}
+
+type evt struct {
+	Type string `json:"type"`
+}
+
+func (e evt) GetType() string {
+	return e.Type
+}
+
+// Event types. See Event.
+const (
+	EVENT_CARD_PLAYED     = "card_played"
+	EVENT_FACEOFF_WON     = "faceoff_won"
+	EVENT_ENVIDO_RESOLVED = "envido_resolved"
+	EVENT_FLOR_RESOLVED   = "flor_resolved"
+	EVENT_POINTS_AWARDED  = "points_awarded"
+	EVENT_ROUND_FINISHED  = "round_finished"
+	EVENT_ROUND_STARTED   = "round_started"
+	EVENT_GAME_ENDED      = "game_ended"
+)
+
+// Reasons for EventPointsAwarded.
+const (
+	POINTS_REASON_ENVIDO  = "envido"
+	POINTS_REASON_FLOR    = "flor"
+	POINTS_REASON_TRUCO   = "truco"
+	POINTS_REASON_MAZO    = "mazo"
+	POINTS_REASON_FORFEIT = "forfeit"
+)
+
+// EventCardPlayed is emitted when a player reveals a card on the table.
+type EventCardPlayed struct {
+	evt
+	PlayerID int  `json:"playerID"`
+	Card     Card `json:"card"`
+}
+
+// EventFaceoffWon is emitted when all players of the round played their card of a faceoff.
+// Faceoff is its index (from 0 to 2), and WinnerPlayerID is -1 if it was a tie ("parda").
+type EventFaceoffWon struct {
+	evt
+	Faceoff        int `json:"faceoff"`
+	WinnerPlayerID int `json:"winnerPlayerID"`
+}
+
+// EventEnvidoResolved is emitted when the envido winner is known, i.e. after a "no quiero",
+// "son buenas" or "son mejores". The points are awarded later if the winner still has to reveal
+// their envido score; EventPointsAwarded is emitted then.
+type EventEnvidoResolved struct {
+	evt
+	WinnerPlayerID int  `json:"winnerPlayerID"`
+	Points         int  `json:"points"`
+	WasAccepted    bool `json:"wasAccepted"`
+}
+
+// EventFlorResolved is like EventEnvidoResolved, but for the flor.
+type EventFlorResolved struct {
+	evt
+	WinnerPlayerID int  `json:"winnerPlayerID"`
+	Points         int  `json:"points"`
+	WasAccepted    bool `json:"wasAccepted"`
+}
+
+// EventPointsAwarded is emitted when a team scores points. Reason is one of the POINTS_REASON_*
+// constants.
+type EventPointsAwarded struct {
+	evt
+	TeamID int    `json:"teamID"`
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+// EventRoundFinished is emitted when a round finishes, before players confirm it.
+type EventRoundFinished struct {
+	evt
+	RoundNumber int `json:"roundNumber"`
+}
+
+// EventRoundStarted is emitted when a round starts, with the same information as GameState.
+type EventRoundStarted struct {
+	evt
+	RoundNumber       int    `json:"roundNumber"`
+	RoundTurnPlayerID int    `json:"roundTurnPlayerID"`
+	RoundType         string `json:"roundType"`
+	PicaPicaDuel      int    `json:"picaPicaDuel"`
+}
+
+// EventGameEnded is emitted when the game ends.
+type EventGameEnded struct {
+	evt
+	WinnerPlayerID int `json:"winnerPlayerID"`
+}
+
+// emit adds an event to the events of the action being run.
+func (g *GameState) emit(e Event) {
+	g.Events = append(g.Events, e)
+}
+
+func SerializeEvent(event Event) []byte {
+	bs, _ := json.Marshal(event)
+	return bs
+}
+
+func DeserializeEvent(bs []byte) (Event, error) {
+	var eventType struct {
+		Type string `json:"type"`
+	}
+
+	err := json.Unmarshal(bs, &eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	switch eventType.Type {
+	case EVENT_CARD_PLAYED:
+		event = &EventCardPlayed{}
+	case EVENT_FACEOFF_WON:
+		event = &EventFaceoffWon{}
+	case EVENT_ENVIDO_RESOLVED:
+		event = &EventEnvidoResolved{}
+	case EVENT_FLOR_RESOLVED:
+		event = &EventFlorResolved{}
+	case EVENT_POINTS_AWARDED:
+		event = &EventPointsAwarded{}
+	case EVENT_ROUND_FINISHED:
+		event = &EventRoundFinished{}
+	case EVENT_ROUND_STARTED:
+		event = &EventRoundStarted{}
+	case EVENT_GAME_ENDED:
+		event = &EventGameEnded{}
+	default:
+		return nil, fmt.Errorf("unknown event: [%v]", string(bs))
+	}
+
+	err = json.Unmarshal(bs, event)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func _serializeEvents(es []Event) []json.RawMessage {
+	_es := []json.RawMessage{}
+	for _, e := range es {
+		_es = append(_es, json.RawMessage(SerializeEvent(e)))
+	}
+	return _es
+}
@@ -0,0 +1,113 @@
+package truco
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}},   // 25
+		{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}}, // 5
+	}
+	gameState := New(withDeck(newTestDeck(hands)))
+	require.Equal(t, []Event{
+		&EventRoundStarted{evt: evt{Type: EVENT_ROUND_STARTED}, RoundNumber: 1, RoundTurnPlayerID: 0, RoundType: ROUND_TYPE_NORMAL},
+	}, gameState.Events)
+
+	steps := []struct {
+		action         Action
+		expectedEvents []Event
+	}{
+		{action: NewActionSayEnvido(0)},
+		{action: NewActionSayEnvidoQuiero(1)},
+		{action: NewActionSayEnvidoScore(0)},
+		{
+			action: NewActionSaySonBuenas(1),
+			expectedEvents: []Event{
+				&EventEnvidoResolved{evt: evt{Type: EVENT_ENVIDO_RESOLVED}, WinnerPlayerID: 0, Points: 2, WasAccepted: true},
+			},
+		},
+		{
+			action: NewActionRevealCard(Card{Number: 2, Suit: ORO}, 0),
+			expectedEvents: []Event{
+				&EventCardPlayed{evt: evt{Type: EVENT_CARD_PLAYED}, PlayerID: 0, Card: Card{Number: 2, Suit: ORO}},
+			},
+		},
+		{
+			action: NewActionRevealCard(Card{Number: 4, Suit: COPA}, 1),
+			expectedEvents: []Event{
+				&EventCardPlayed{evt: evt{Type: EVENT_CARD_PLAYED}, PlayerID: 1, Card: Card{Number: 4, Suit: COPA}},
+				&EventFaceoffWon{evt: evt{Type: EVENT_FACEOFF_WON}, Faceoff: 0, WinnerPlayerID: 0},
+			},
+		},
+		{action: NewActionSayTruco(0)},
+		{action: NewActionSayTrucoQuiero(1)},
+		{
+			// Revealing the card reveals the envido score too, so the envido points are awarded.
+			action: NewActionRevealCard(Card{Number: 3, Suit: ORO}, 0),
+			expectedEvents: []Event{
+				&EventCardPlayed{evt: evt{Type: EVENT_CARD_PLAYED}, PlayerID: 0, Card: Card{Number: 3, Suit: ORO}},
+				&EventPointsAwarded{evt: evt{Type: EVENT_POINTS_AWARDED}, TeamID: 0, Points: 2, Reason: POINTS_REASON_ENVIDO},
+			},
+		},
+		{
+			action: NewActionRevealCard(Card{Number: 5, Suit: ORO}, 1),
+			expectedEvents: []Event{
+				&EventCardPlayed{evt: evt{Type: EVENT_CARD_PLAYED}, PlayerID: 1, Card: Card{Number: 5, Suit: ORO}},
+				&EventFaceoffWon{evt: evt{Type: EVENT_FACEOFF_WON}, Faceoff: 1, WinnerPlayerID: 0},
+				&EventPointsAwarded{evt: evt{Type: EVENT_POINTS_AWARDED}, TeamID: 0, Points: 2, Reason: POINTS_REASON_TRUCO},
+				&EventRoundFinished{evt: evt{Type: EVENT_ROUND_FINISHED}, RoundNumber: 1},
+			},
+		},
+		{action: NewActionConfirmRoundFinished(0)},
+		{
+			action: NewActionConfirmRoundFinished(1),
+			expectedEvents: []Event{
+				&EventRoundStarted{evt: evt{Type: EVENT_ROUND_STARTED}, RoundNumber: 2, RoundTurnPlayerID: 1, RoundType: ROUND_TYPE_NORMAL},
+			},
+		},
+		{
+			action: NewActionSayMeVoyAlMazo(1),
+			expectedEvents: []Event{
+				&EventPointsAwarded{evt: evt{Type: EVENT_POINTS_AWARDED}, TeamID: 0, Points: 2, Reason: POINTS_REASON_MAZO},
+				&EventRoundFinished{evt: evt{Type: EVENT_ROUND_FINISHED}, RoundNumber: 2},
+			},
+		},
+		{
+			action: NewActionForfeit(0),
+			expectedEvents: []Event{
+				&EventPointsAwarded{evt: evt{Type: EVENT_POINTS_AWARDED}, TeamID: 1, Points: 30, Reason: POINTS_REASON_FORFEIT},
+				&EventGameEnded{evt: evt{Type: EVENT_GAME_ENDED}, WinnerPlayerID: 1},
+			},
+		},
+	}
+
+	for i, step := range steps {
+		step.action.Enrich(*gameState)
+		require.NoError(t, gameState.RunAction(step.action), "at step %v", i)
+		require.Equal(t, step.expectedEvents, gameState.Events, "at step %v", i)
+
+		// Events are available to clients too, and they can be deserialized.
+		clientEvents := gameState.ToClientGameState(1).Events
+		require.Len(t, clientEvents, len(step.expectedEvents), "at step %v", i)
+		for j, bs := range clientEvents {
+			event, err := DeserializeEvent(bs)
+			require.NoError(t, err, "at step %v", i)
+			require.Equal(t, step.expectedEvents[j], event, "at step %v", i)
+		}
+	}
+}
+
+func TestFlorEvents(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: COPA}, {Number: 3, Suit: COPA}}}, // 26
+		{Unrevealed: []Card{{Number: 4, Suit: ORO}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}},  // no flor
+	}
+	gameState := New(withDeck(newTestDeck(hands)), WithFlorEnabled(true))
+	require.NoError(t, gameState.RunAction(NewActionSayFlor(0)))
+	require.Equal(t, []Event{
+		&EventFlorResolved{evt: evt{Type: EVENT_FLOR_RESOLVED}, WinnerPlayerID: 0, Points: 3, WasAccepted: true},
+	}, gameState.Events)
+}
@@ -109,13 +109,13 @@ func (es *FlorSequence) IsFinished() bool {
 	return last == SAY_FLOR_SON_BUENAS || last == SAY_FLOR_SON_MEJORES || last == SAY_CON_FLOR_ME_ACHICO || (last == SAY_FLOR && es.IsSinglePlayerFlor)
 }
 
-func (es FlorSequence) Cost(maxPoints, winnerPlayerScore, loserPlayerScore int, forHint bool) (int, error) {
+func (es FlorSequence) Cost(rules Rules, winnerPlayerScore, loserPlayerScore int, forHint bool) (int, error) {
 	if !es.isValid() {
 		return COST_NOT_READY, fmt.Errorf("%w: [%v]", errInvalidFlorSequence, strings.Join(es.Sequence, ","))
 	}
 	cost := validFlorSequenceCosts[es.String()]
 	if cost == COST_CONTRAFLOR_AL_RESTO {
-		return calculateFaltaEnvidoCost(maxPoints, winnerPlayerScore, loserPlayerScore), nil
+		return calculateFaltaEnvidoCost(rules, winnerPlayerScore, loserPlayerScore), nil
 	}
 	// If this calculation is for enriching an action, we don't care if it's finished.
 	// If it's for assigning cost, then it must be finished.
@@ -139,9 +139,10 @@ func (es FlorSequence) WasAccepted() bool {
 
 func (es FlorSequence) Clone() *FlorSequence {
 	return &FlorSequence{
-		Sequence:          append([]string{}, es.Sequence...),
-		StartingPlayerID:  es.StartingPlayerID,
-		FlorPointsAwarded: es.FlorPointsAwarded,
+		Sequence:           _cloneSlice(es.Sequence),
+		IsSinglePlayerFlor: es.IsSinglePlayerFlor,
+		StartingPlayerID:   es.StartingPlayerID,
+		FlorPointsAwarded:  es.FlorPointsAwarded,
 	}
 }
 
@@ -0,0 +1,89 @@
+package truco
+
+// ClientRoundLog is what a player can see of a round that was played, or is being played. It's
+// like RoundLog, but it only has the cards that everybody saw, and the player's own hand.
+type ClientRoundLog struct {
+	// RoundNumber is the round's number, starting from 1.
+	RoundNumber int `json:"roundNumber"`
+
+	// RoundTurnPlayerID is the player who started the round, or "mano".
+	RoundTurnPlayerID int `json:"roundTurnPlayerID"`
+
+	// Type, PicaPicaDuel and PlayerIDs are as in RoundLog.
+	Type         string `json:"type"`
+	PicaPicaDuel int    `json:"picaPicaDuel"`
+	PlayerIDs    []int  `json:"playerIDs"`
+
+	// YourHandDealt has the cards you were dealt for the round, or nil if you weren't dealt any,
+	// e.g. in a pica-pica duel you don't play.
+	YourHandDealt []Card `json:"yourHandDealt"`
+
+	// RevealedCards are the cards each player of the round showed, by player ID: either played in
+	// a faceoff, or shown to prove their envido or flor score. Cards that weren't shown stay hidden.
+	RevealedCards map[int][]Card `json:"revealedCards"`
+
+	// Results of the round, as in RoundLog.
+	TeamPoints           [2]int      `json:"teamPoints"`
+	FlorWinnerPlayerID   int         `json:"florWinnerPlayerID"`
+	FlorPoints           int         `json:"florPoints"`
+	EnvidoWinnerPlayerID int         `json:"envidoWinnerPlayerID"`
+	EnvidoPoints         int         `json:"envidoPoints"`
+	TrucoWinnerPlayerID  int         `json:"trucoWinnerPlayerID"`
+	TrucoPoints          int         `json:"trucoPoints"`
+	Mazo                 *MazoLog    `json:"mazo,omitempty"`
+	ActionsLog           []ActionLog `json:"actionsLog"`
+	ScoresLog            []ScoreLog  `json:"scoresLog"`
+}
+
+// logRevealedCards saves the cards revealed by the players of the current round in its log, so
+// that they can be shown once the round is over and the next hands are dealt.
+func (g *GameState) logRevealedCards() {
+	revealedCards := map[int][]Card{}
+	for _, playerID := range g.RoundPlayerIDs {
+		revealedCards[playerID] = _cloneSlice(g.Players[playerID].Hand.Revealed)
+	}
+	g.RoundsLog[g.RoundNumber].RevealedCards = revealedCards
+}
+
+// clientHistory returns the log of every round so far as seen by the given player, the last one
+// being the current round.
+func (g GameState) clientHistory(youPlayerID int) []ClientRoundLog {
+	history := []ClientRoundLog{}
+	for roundNumber := 1; roundNumber <= g.RoundNumber; roundNumber++ {
+		roundLog := g.RoundsLog[roundNumber]
+		clientRoundLog := ClientRoundLog{
+			RoundNumber:          roundNumber,
+			RoundTurnPlayerID:    -1,
+			Type:                 roundLog.Type,
+			PicaPicaDuel:         roundLog.PicaPicaDuel,
+			PlayerIDs:            roundLog.PlayerIDs,
+			RevealedCards:        roundLog.RevealedCards,
+			TeamPoints:           roundLog.TeamPoints,
+			FlorWinnerPlayerID:   roundLog.FlorWinnerPlayerID,
+			FlorPoints:           roundLog.FlorPoints,
+			EnvidoWinnerPlayerID: roundLog.EnvidoWinnerPlayerID,
+			EnvidoPoints:         roundLog.EnvidoPoints,
+			TrucoWinnerPlayerID:  roundLog.TrucoWinnerPlayerID,
+			TrucoPoints:          roundLog.TrucoPoints,
+			Mazo:                 roundLog.Mazo,
+			ActionsLog:           roundLog.ActionsLog,
+			ScoresLog:            roundLog.ScoresLog,
+		}
+		if len(roundLog.PlayerIDs) > 0 {
+			clientRoundLog.RoundTurnPlayerID = roundLog.PlayerIDs[0]
+		}
+		if dealt := roundLog.HandsDealt[youPlayerID]; dealt != nil {
+			// Older logs have the hands as they were at the end of the round, so put back the revealed cards.
+			clientRoundLog.YourHandDealt = append(append([]Card{}, dealt.Unrevealed...), dealt.Revealed...)
+		}
+		// The current round's cards are still being revealed, so they are taken from the hands.
+		if roundNumber == g.RoundNumber {
+			clientRoundLog.RevealedCards = map[int][]Card{}
+			for _, playerID := range g.RoundPlayerIDs {
+				clientRoundLog.RevealedCards[playerID] = g.Players[playerID].Hand.Revealed
+			}
+		}
+		history = append(history, clientRoundLog)
+	}
+	return history
+}
@@ -0,0 +1,67 @@
+package truco
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientHistory(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}},   // 25
+		{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}}, // 5
+	}
+	gameState := New(withDeck(newTestDeck(hands)))
+	actions := []Action{
+		NewActionSayEnvido(0),
+		NewActionSayEnvidoQuiero(1),
+		NewActionSayEnvidoScore(0),
+		NewActionSaySonBuenas(1),
+		NewActionRevealCard(Card{Number: 1, Suit: COPA}, 0),
+		NewActionRevealCard(Card{Number: 4, Suit: COPA}, 1),
+		NewActionSayTruco(0),
+		NewActionSayTrucoNoQuiero(1),
+		NewActionRevealEnvidoScore(0),
+	}
+	for i, action := range actions {
+		action.Enrich(*gameState)
+		require.NoError(t, gameState.RunAction(action), "at action %v", i)
+	}
+
+	// While the round isn't confirmed, it's the current one, and its cards come from the hands.
+	history := gameState.ToClientGameState(1).History
+	require.Len(t, history, 1)
+	require.Equal(t, []Card{{Number: 4, Suit: COPA}}, history[0].RevealedCards[1])
+
+	require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(0)))
+	require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(1)))
+
+	history = gameState.ToClientGameState(1).History
+	require.Len(t, history, 2)
+	round := history[0]
+	require.Equal(t, 1, round.RoundNumber)
+	require.Equal(t, 0, round.RoundTurnPlayerID)
+	require.Equal(t, hands[1].Unrevealed, round.YourHandDealt)
+	require.Equal(t, map[int][]Card{
+		// Player 0 showed their envido, so the cards that make it up are revealed too.
+		0: {{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}},
+		1: {{Number: 4, Suit: COPA}},
+	}, round.RevealedCards)
+	require.Equal(t, 0, round.EnvidoWinnerPlayerID)
+	require.Equal(t, 0, round.TrucoWinnerPlayerID)
+	require.Equal(t, [2]int{3, 0}, round.TeamPoints)
+	require.Len(t, round.ActionsLog, len(actions))
+	require.Len(t, round.ScoresLog, 2)
+	require.Equal(t, 2, history[1].RoundNumber)
+	require.Equal(t, 1, history[1].RoundTurnPlayerID)
+
+	// Player 0 never sees the cards that player 1 didn't show in the first round.
+	bs, err := json.Marshal(gameState.ToClientGameState(0).History[0])
+	require.NoError(t, err)
+	for _, card := range []Card{{Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}} {
+		cardBs, _ := json.Marshal(card)
+		require.NotContains(t, string(bs), string(cardBs))
+	}
+	require.Equal(t, hands[0].Unrevealed, gameState.ToClientGameState(0).History[0].YourHandDealt)
+}
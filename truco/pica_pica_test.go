@@ -0,0 +1,124 @@
+package truco
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPicaPicaRounds(t *testing.T) {
+	gameState := New(WithPlayerCount(6), WithPicaPica(1, 10))
+	require.Equal(t, ROUND_TYPE_NORMAL, gameState.RoundType)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5}, gameState.RoundPlayerIDs)
+
+	// Player 0 goes to the mazo before envido, so the other team scores 2.
+	require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+	require.Equal(t, [2]int{0, 2}, gameState.RoundsLog[1].TeamPoints)
+	confirmAll(t, gameState)
+
+	// The leading team has 2 points, so a pica-pica round starts with the next mano.
+	dealt := gameState.RoundsLog[2].HandsDealt
+	require.Len(t, dealt, 6)
+	for duel, expectedPlayerIDs := range [][]int{{1, 4}, {2, 5}, {3, 0}} {
+		require.Equal(t, 2+duel, gameState.RoundNumber)
+		require.Equal(t, ROUND_TYPE_PICA_PICA, gameState.RoundType)
+		require.Equal(t, duel, gameState.PicaPicaDuel)
+		require.Equal(t, expectedPlayerIDs, gameState.RoundPlayerIDs)
+		require.Equal(t, expectedPlayerIDs[0], gameState.TurnPlayerID)
+		require.Equal(t, expectedPlayerIDs[1], gameState.OpponentOf(expectedPlayerIDs[0]))
+
+		// Cards were dealt for the first duel, and they are kept for the others.
+		for _, playerID := range expectedPlayerIDs {
+			require.Equal(t, dealt[playerID].Unrevealed, gameState.Players[playerID].Hand.Unrevealed)
+		}
+
+		// Only the duel's players reveal cards.
+		mano, opponent := expectedPlayerIDs[0], expectedPlayerIDs[1]
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[mano].Hand.Unrevealed[0], mano)))
+		require.Equal(t, opponent, gameState.TurnPlayerID)
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(opponent)))
+		require.Equal(t, 2, gameState.RoundsLog[gameState.RoundNumber].TeamPoints[gameState.TeamOf(mano)])
+		confirmAll(t, gameState)
+	}
+
+	// Then, a normal round with the mano after the one who started the pica-pica round.
+	require.Equal(t, 5, gameState.RoundNumber)
+	require.Equal(t, ROUND_TYPE_NORMAL, gameState.RoundType)
+	require.Equal(t, 2, gameState.RoundTurnPlayerID)
+	require.Equal(t, []int{2, 3, 4, 5, 0, 1}, gameState.RoundPlayerIDs)
+
+	// Each duel's mano won 2 points: teams 1, 0 and 1.
+	require.Equal(t, 2, gameState.Players[0].Score)
+	require.Equal(t, 6, gameState.Players[1].Score)
+}
+
+func TestManoIsAlwaysAPlayerAfterPicaPicaRounds(t *testing.T) {
+	gameState := New(WithPlayerCount(6), WithPicaPica(1, 29))
+	startedBy := map[int]bool{}
+	for !gameState.IsGameEnded {
+		require.GreaterOrEqual(t, gameState.RoundTurnPlayerID, 0)
+		require.Less(t, gameState.RoundTurnPlayerID, 6)
+		require.Equal(t, gameState.RoundTurnPlayerID, gameState.ToClientGameState(0).RoundTurnPlayerID)
+		if gameState.RoundType == ROUND_TYPE_PICA_PICA && gameState.PicaPicaDuel == 0 {
+			startedBy[gameState.RoundTurnPlayerID] = true
+		}
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(gameState.TurnPlayerID)))
+		if !gameState.IsGameEnded {
+			confirmAll(t, gameState)
+		}
+	}
+
+	// Pica-pica rounds started by the last players wrap around the table.
+	require.True(t, startedBy[4] || startedBy[5])
+}
+
+func TestPicaPicaIsOnlyPlayedInTheScoreRange(t *testing.T) {
+	gameState := New(WithPlayerCount(6), WithPicaPica(5, 25))
+	for round := 0; round < 10; round++ {
+		require.Equal(t, ROUND_TYPE_NORMAL, gameState.RoundType)
+		require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(gameState.TurnPlayerID)))
+		confirmAll(t, gameState)
+		if gameState.Players[0].Score >= 5 || gameState.Players[1].Score >= 5 {
+			break
+		}
+	}
+	require.Equal(t, ROUND_TYPE_PICA_PICA, gameState.RoundType)
+
+	// It's ignored unless there are six players.
+	gameState = New(WithPlayerCount(4), WithPicaPica(0, 25))
+	require.Equal(t, 0, gameState.Rules.PicaPicaTo)
+	require.Equal(t, ROUND_TYPE_NORMAL, gameState.RoundType)
+}
+
+func TestSixPlayerGamesPlayToTheEnd(t *testing.T) {
+	for seed := int64(0); seed < 30; seed++ {
+		opts := []func(*GameState){WithPlayerCount(6), WithMaxPoints(15)}
+		if seed%2 == 0 {
+			opts = append(opts, WithPicaPica(2, 12))
+		}
+		gameState := playRandomGame(t, seed, opts...)
+		require.Equal(t, 15, gameState.Players[gameState.WinnerPlayerID].Score)
+
+		// The team points of every round add up to the final scores, unless they went over the max.
+		teamPoints := [2]int{}
+		for _, roundLog := range gameState.RoundsLog[1:] {
+			teamPoints[0] += roundLog.TeamPoints[0]
+			teamPoints[1] += roundLog.TeamPoints[1]
+		}
+		for playerID := 0; playerID < 6; playerID++ {
+			require.Equal(t, min(teamPoints[playerID%2], 15), gameState.Players[playerID].Score)
+		}
+
+		replayer, err := Replay(gameState.RoundsLog, opts...)
+		require.NoError(t, err)
+		for replayer.Next() {
+		}
+		require.Equal(t, serializeForTest(t, gameState.RoundsLog), serializeForTest(t, replayer.GameState().RoundsLog))
+	}
+}
+
+func confirmAll(t *testing.T, gameState *GameState) {
+	for i := 0; i < len(gameState.Players); i++ {
+		require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(gameState.TurnPlayerID)))
+	}
+}
@@ -0,0 +1,187 @@
+package truco
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Replayer steps through a recorded game, e.g. for post-game review or to reproduce a bug.
+//
+// Use Replay to create one from a game's RoundsLog. It starts at the beginning of the first
+// round, and it can step forward and backward, or seek to any (round, action) position.
+type Replayer struct {
+	roundsLog []*RoundLog
+	gameState *GameState
+
+	// roundStarts has the serialized GameState at the start of each round (1-indexed, like
+	// RoundsLog), so that seeking doesn't need to replay the whole game.
+	roundStarts [][]byte
+
+	roundNumber int
+	actionIndex int
+}
+
+var (
+	errReplayEmptyLog          = errors.New("rounds log has no rounds")
+	errReplayPositionNotInGame = errors.New("position is not in the game")
+	errReplayWrongRoundNumber  = errors.New("replay dealt a round that is not in the log")
+)
+
+// Replay rebuilds a game from its RoundsLog, forcing the hands that were dealt on each round.
+//
+// Pass the same options the game was created with (e.g. WithMaxPoints), since they aren't part
+// of the log. Every action is validated against the rebuilt game state, so an error is returned
+// if the log doesn't describe a valid game.
+//
+// Confirming the end of a round isn't logged, so the replay confirms it for both players
+// before moving on to the next round.
+func Replay(roundsLog []*RoundLog, opts ...func(*GameState)) (*Replayer, error) {
+	if len(roundsLog) < 2 {
+		return nil, errReplayEmptyLog
+	}
+	r := &Replayer{roundsLog: roundsLog, roundStarts: make([][]byte, len(roundsLog))}
+	r.gameState = New(append(opts, WithDealer(r.deal))...)
+	r.roundNumber = 1
+
+	// Replay the whole game once, both to validate it and to take a snapshot of each round's start.
+	for {
+		if r.actionIndex == 0 {
+			bs, err := r.gameState.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			r.roundStarts[r.roundNumber] = bs
+		}
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := r.Seek(1, 0); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// deal is the replay's Dealer: it deals the hands in the log.
+func (r *Replayer) deal(roundNumber int, playerID int) Hand {
+	if roundNumber >= len(r.roundsLog) {
+		return Hand{}
+	}
+	dealt := r.roundsLog[roundNumber].HandsDealt[playerID]
+	if dealt == nil {
+		return Hand{}
+	}
+	// Older logs have the hands as they were at the end of the round, so put back the revealed cards.
+	return Hand{Unrevealed: append(append([]Card{}, dealt.Unrevealed...), dealt.Revealed...)}
+}
+
+// GameState returns the game state at the current position. It must not be modified; use
+// the Replayer's methods to move around instead.
+func (r *Replayer) GameState() *GameState {
+	return r.gameState
+}
+
+// Position returns the current round number, and how many of its actions have been run.
+func (r *Replayer) Position() (roundNumber int, actionIndex int) {
+	return r.roundNumber, r.actionIndex
+}
+
+// RoundCount returns the number of rounds in the game.
+func (r *Replayer) RoundCount() int {
+	return len(r.roundsLog) - 1
+}
+
+// ActionCount returns the number of actions logged in the given round.
+func (r *Replayer) ActionCount(roundNumber int) int {
+	if roundNumber < 1 || roundNumber >= len(r.roundsLog) {
+		return 0
+	}
+	return len(r.roundsLog[roundNumber].ActionsLog)
+}
+
+// Next runs the next action, or starts the next round if all actions of the current one were
+// run. It returns false if the end of the game was reached.
+func (r *Replayer) Next() bool {
+	ok, err := r.next()
+	if err != nil {
+		// Unreachable: the whole log was validated by Replay.
+		panic(err)
+	}
+	return ok
+}
+
+// Prev goes back to the previous position. It returns false if the start of the game was reached.
+func (r *Replayer) Prev() bool {
+	switch {
+	case r.actionIndex > 0:
+		return r.Seek(r.roundNumber, r.actionIndex-1) == nil
+	case r.roundNumber > 1:
+		return r.Seek(r.roundNumber-1, r.ActionCount(r.roundNumber-1)) == nil
+	default:
+		return false
+	}
+}
+
+// Seek moves to the given round number, after running the given number of its actions.
+func (r *Replayer) Seek(roundNumber int, actionIndex int) error {
+	if roundNumber < 1 || roundNumber >= len(r.roundsLog) || actionIndex < 0 || actionIndex > r.ActionCount(roundNumber) {
+		return fmt.Errorf("%w: round %v action %v", errReplayPositionNotInGame, roundNumber, actionIndex)
+	}
+	gameState, err := DeserializeGameState(r.roundStarts[roundNumber])
+	if err != nil {
+		return err
+	}
+	gameState.dealer = r.deal
+	r.gameState = gameState
+	r.roundNumber = roundNumber
+	r.actionIndex = 0
+	for r.actionIndex < actionIndex {
+		if _, err := r.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Replayer) next() (bool, error) {
+	actionsLog := r.roundsLog[r.roundNumber].ActionsLog
+	if r.actionIndex < len(actionsLog) {
+		action, err := DeserializeAction(actionsLog[r.actionIndex].Action)
+		if err != nil {
+			return false, fmt.Errorf("round %v action %v: %w", r.roundNumber, r.actionIndex, err)
+		}
+		if err := r.runAction(action); err != nil {
+			return false, fmt.Errorf("round %v action %v: %w", r.roundNumber, r.actionIndex, err)
+		}
+		r.actionIndex++
+		return true, nil
+	}
+
+	if r.roundNumber == len(r.roundsLog)-1 || r.gameState.IsGameEnded {
+		return false, nil
+	}
+
+	// Move on to the next round, which requires both players to confirm the current one finished.
+	for playerID := 0; playerID < len(r.gameState.Players); playerID++ {
+		if err := r.runAction(NewActionConfirmRoundFinished(playerID)); err != nil {
+			return false, fmt.Errorf("round %v confirming round finished: %w", r.roundNumber, err)
+		}
+	}
+	if r.gameState.RoundNumber != r.roundNumber+1 {
+		return false, fmt.Errorf("round %v: %w", r.roundNumber, errReplayWrongRoundNumber)
+	}
+	r.roundNumber++
+	r.actionIndex = 0
+	return true, nil
+}
+
+func (r *Replayer) runAction(action Action) error {
+	if !action.IsPossible(*r.gameState) {
+		return fmt.Errorf("%w: [%v]", ErrActionNotPossible, action)
+	}
+	return r.gameState.RunAction(action)
+}
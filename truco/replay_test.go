@@ -0,0 +1,101 @@
+package truco
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// playRandomGame plays a game to the end, choosing actions at random.
+func playRandomGame(t *testing.T, seed int64, opts ...func(*GameState)) *GameState {
+	var (
+		r         = rand.New(rand.NewSource(seed))
+		gameState = New(append([]func(*GameState){WithSeed(seed)}, opts...)...)
+	)
+	for !gameState.IsGameEnded {
+		actions := gameState.CalculatePossibleActions()
+		require.NotEmpty(t, actions)
+		err := gameState.RunAction(actions[r.Intn(len(actions))])
+		if errors.Is(err, ErrNotYourTurn) {
+			continue
+		}
+		require.NoError(t, err)
+	}
+	return gameState
+}
+
+func TestReplayRebuildsTheGame(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		opts := []func(*GameState){WithMaxPoints(15), WithFlorEnabled(seed%2 == 0)}
+		original := playRandomGame(t, seed, opts...)
+
+		replayer, err := Replay(original.RoundsLog, opts...)
+		require.NoError(t, err)
+		roundNumber, actionIndex := replayer.Position()
+		require.Equal(t, 1, roundNumber)
+		require.Equal(t, 0, actionIndex)
+		require.Equal(t, original.RoundsLog[1].HandsDealt[0].Unrevealed, replayer.GameState().Players[0].Hand.Unrevealed)
+
+		// Step forward to the end, keeping every state on the way.
+		states := []string{serializeForTest(t, replayer.GameState())}
+		for replayer.Next() {
+			states = append(states, serializeForTest(t, replayer.GameState()))
+		}
+		replayed := replayer.GameState()
+		require.True(t, replayed.IsGameEnded)
+		require.Equal(t, original.WinnerPlayerID, replayed.WinnerPlayerID)
+		require.Equal(t, original.Players[0].Score, replayed.Players[0].Score)
+		require.Equal(t, original.Players[1].Score, replayed.Players[1].Score)
+		require.Equal(t, serializeForTest(t, original.RoundsLog), serializeForTest(t, replayed.RoundsLog))
+		require.Equal(t, original.ToClientGameState(0), replayed.ToClientGameState(0))
+		require.Equal(t, original.ToClientGameState(1), replayed.ToClientGameState(1))
+
+		// Step backward to the start, checking that every state is the same as on the way forward.
+		for i := len(states) - 2; i >= 0; i-- {
+			require.True(t, replayer.Prev())
+			require.Equal(t, states[i], serializeForTest(t, replayer.GameState()), "seed %v state %v", seed, i)
+		}
+		require.False(t, replayer.Prev())
+
+		// Seek to the middle of the last round.
+		lastRound := replayer.RoundCount()
+		require.NoError(t, replayer.Seek(lastRound, 1))
+		roundNumber, actionIndex = replayer.Position()
+		require.Equal(t, lastRound, roundNumber)
+		require.Equal(t, 1, actionIndex)
+		require.Len(t, replayer.GameState().RoundsLog[lastRound].ActionsLog, 1)
+		require.Error(t, replayer.Seek(lastRound+1, 0))
+		require.Error(t, replayer.Seek(lastRound, replayer.ActionCount(lastRound)+1))
+	}
+}
+
+func TestReplayRejectsInvalidLogs(t *testing.T) {
+	original := playRandomGame(t, 1, WithMaxPoints(15))
+
+	// Tamper with the first action, so that it's a card that isn't in the player's hand.
+	var roundsLog []*RoundLog
+	require.NoError(t, json.Unmarshal([]byte(serializeForTest(t, original.RoundsLog)), &roundsLog))
+	firstHand := roundsLog[1].HandsDealt[0].Unrevealed
+	notInHand := Card{Suit: ORO, Number: 1}
+	for _, card := range firstHand {
+		if card == notInHand {
+			notInHand = Card{Suit: COPA, Number: 1}
+		}
+	}
+	roundsLog[1].ActionsLog[0].Action = SerializeAction(NewActionRevealCard(notInHand, 0))
+
+	_, err := Replay(roundsLog, WithMaxPoints(15))
+	require.ErrorIs(t, err, ErrActionNotPossible)
+
+	_, err = Replay([]*RoundLog{{}})
+	require.Error(t, err)
+}
+
+func serializeForTest(t *testing.T, v any) string {
+	bs, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(bs)
+}
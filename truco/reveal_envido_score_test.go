@@ -107,3 +107,63 @@ func TestRevealEnvidoScore(t *testing.T) {
 		})
 	}
 }
+
+func TestRevealEnvidoScoreToWinTheGame(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithMaxPoints(15), WithDealer(dealerWithHands(map[int][]Card{
+		0: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: ESPADA, Number: 6}},   // 6
+		1: {{Suit: ORO, Number: 7}, {Suit: ORO, Number: 1}, {Suit: BASTO, Number: 11}},    // 28
+		2: {{Suit: ESPADA, Number: 7}, {Suit: ESPADA, Number: 5}, {Suit: ORO, Number: 4}}, // 32
+		3: {{Suit: BASTO, Number: 2}, {Suit: COPA, Number: 10}, {Suit: ORO, Number: 12}},  // 2
+	})))
+	gameState.Players[0].Score, gameState.Players[2].Score = 14, 14
+
+	require.NoError(t, gameState.RunAction(NewActionSayEnvido(0)))
+	require.NoError(t, gameState.RunAction(NewActionSayEnvidoQuiero(1)))
+	require.NoError(t, gameState.RunAction(NewActionSayEnvidoScore(0)))
+	require.NoError(t, gameState.RunAction(NewActionSaySonBuenas(1)))
+	require.Equal(t, 2, gameState.RoundsLog[1].EnvidoWinnerPlayerID)
+	require.Equal(t, 0, gameState.TurnPlayerID)
+
+	// The envido wins player 2's team the game, but they can only reveal their score on their turn.
+	require.False(t, NewActionRevealEnvidoScore(2).IsPossible(*gameState))
+	for _, action := range gameState.CalculatePossibleActions() {
+		require.Equal(t, 0, action.GetPlayerID())
+	}
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: COPA, Number: 4}, 0)))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: ORO, Number: 7}, 1)))
+	require.Equal(t, 2, gameState.TurnPlayerID)
+	require.NoError(t, gameState.RunAction(NewActionRevealEnvidoScore(2)))
+
+	// Nobody can do anything once the game ended.
+	require.True(t, gameState.IsGameEnded)
+	require.Equal(t, 0, gameState.TeamOf(gameState.WinnerPlayerID))
+	require.Empty(t, gameState.CalculatePossibleActions())
+}
+
+func TestRevealFlorScoreToWinTheGame(t *testing.T) {
+	gameState := New(WithMaxPoints(15), WithFlorEnabled(true), WithDealer(dealerWithHands(map[int][]Card{
+		0: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: BASTO, Number: 6}},
+		1: {{Suit: ESPADA, Number: 1}, {Suit: ESPADA, Number: 2}, {Suit: ESPADA, Number: 3}},
+	})))
+	gameState.Players[1].Score = 14
+
+	// Answering the envido with flor yields the turn back to player 0.
+	require.NoError(t, gameState.RunAction(NewActionSayEnvido(0)))
+	require.NoError(t, gameState.RunAction(NewActionSayFlor(1)))
+	require.Equal(t, 1, gameState.RoundsLog[1].FlorWinnerPlayerID)
+	require.Equal(t, 0, gameState.TurnPlayerID)
+
+	// The flor wins player 1 the game, but they can only reveal their score on their turn.
+	require.False(t, NewActionRevealFlorScore(1).IsPossible(*gameState))
+	for _, action := range gameState.CalculatePossibleActions() {
+		require.Equal(t, 0, action.GetPlayerID())
+	}
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: COPA, Number: 4}, 0)))
+	require.Equal(t, 1, gameState.TurnPlayerID)
+	require.NoError(t, gameState.RunAction(NewActionRevealFlorScore(1)))
+
+	// Nobody can do anything once the game ended.
+	require.True(t, gameState.IsGameEnded)
+	require.Equal(t, 1, gameState.WinnerPlayerID)
+	require.Empty(t, gameState.CalculatePossibleActions())
+}
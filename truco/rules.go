@@ -0,0 +1,163 @@
+package truco
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Ways to compute the points of a falta envido. See Rules.FaltaEnvido.
+const (
+	// FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS is worth the points the winner needs to leave "malas"
+	// (the first half of the game) while both are in malas, and the points the leader needs
+	// to win otherwise. Only games of more than 15 points have malas.
+	FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS = "points_to_leave_malas"
+
+	// FALTA_ENVIDO_POINTS_TO_WIN is always worth the points the leader needs to win the game.
+	FALTA_ENVIDO_POINTS_TO_WIN = "points_to_win"
+
+	// FALTA_ENVIDO_WINS_GAME_IN_MALAS is the traditional rule: it wins the game outright while
+	// both are in malas, and it's worth the points the leader needs to win otherwise.
+	FALTA_ENVIDO_WINS_GAME_IN_MALAS = "wins_game_in_malas"
+)
+
+// Ways to score a "me voy al mazo" while the envido can still be played. See Rules.Mazo.
+const (
+	// MAZO_ENVIDO_POINT awards an extra point for the envido that can't be played anymore.
+	MAZO_ENVIDO_POINT = "envido_point"
+
+	// MAZO_MANO_BEFORE_FIRST_CARD only awards the extra point if mano goes to the mazo before
+	// revealing a card.
+	MAZO_MANO_BEFORE_FIRST_CARD = "mano_before_first_card"
+
+	// MAZO_ENVIDO_CLAIMABLE lets the opponent claim the envido instead, i.e. they win the points
+	// of an accepted envido if their envido score is better, and show the cards that prove it.
+	// Otherwise, the envido isn't played, and nobody wins points for it.
+	MAZO_ENVIDO_CLAIMABLE = "envido_claimable"
+)
+
+// Stages of the game for each team. See Rules.StageOf.
+const (
+	STAGE_MALAS  = "malas"
+	STAGE_BUENAS = "buenas"
+)
+
+// Rules are the house rules of a game. Their zero value is the standard rules, i.e. the ones
+// the engine has always played by, so only the variants need to be set.
+//
+// Use WithRules to set them, or the options for individual rules (e.g. WithMaxPoints).
+type Rules struct {
+	// MaxPoints is the points a team must reach to win the game. If 0, it's DefaultMaxPoints.
+	MaxPoints int `json:"maxPoints"`
+
+	IsFlorEnabled bool `json:"isFlorEnabled"`
+
+	// IsContraflorDisabled forbids answering a flor with contraflor or contraflor al resto.
+	IsContraflorDisabled bool `json:"isContraflorDisabled"`
+
+	// IsEnvidoKeptAfterFlor allows playing envido after a flor. Normally, saying flor ends the envido.
+	IsEnvidoKeptAfterFlor bool `json:"isEnvidoKeptAfterFlor"`
+
+	// IsRealEnvidoRepeatable allows answering a real envido with another one.
+	IsRealEnvidoRepeatable bool `json:"isRealEnvidoRepeatable"`
+
+	// IsEnvidoAllowedInSecondFaceoff allows saying envido until the second faceoff is over.
+	// Normally, envido can't be said once every player revealed their first card.
+	IsEnvidoAllowedInSecondFaceoff bool `json:"isEnvidoAllowedInSecondFaceoff"`
+
+	// FaltaEnvido is how the points of a falta envido (and contraflor al resto) are computed:
+	// FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS (if empty), FALTA_ENVIDO_POINTS_TO_WIN or
+	// FALTA_ENVIDO_WINS_GAME_IN_MALAS.
+	FaltaEnvido string `json:"faltaEnvido"`
+
+	// Mazo is how a "me voy al mazo" is scored while the envido can still be played:
+	// MAZO_ENVIDO_POINT (if empty), MAZO_MANO_BEFORE_FIRST_CARD or MAZO_ENVIDO_CLAIMABLE.
+	Mazo string `json:"mazo"`
+
+	// IsMazoForbiddenDuringEnvido forbids going to the mazo after an envido was accepted, until
+	// its points are awarded.
+	IsMazoForbiddenDuringEnvido bool `json:"isMazoForbiddenDuringEnvido"`
+
+	// PicaPicaFrom and PicaPicaTo are the score range of pica-pica rounds. See WithPicaPica.
+	PicaPicaFrom int `json:"picaPicaFrom"`
+	PicaPicaTo   int `json:"picaPicaTo"`
+}
+
+// ErrInvalidRules is returned by Rules.Validate. Use errors.Is to check for it, since it's wrapped.
+var ErrInvalidRules = errors.New("invalid rules")
+
+// Validate returns an error if the rules can't be played together.
+func (r Rules) Validate() error {
+	if r.MaxPoints < 0 {
+		return fmt.Errorf("%w: max points must be positive", ErrInvalidRules)
+	}
+	switch r.FaltaEnvido {
+	case "", FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS, FALTA_ENVIDO_POINTS_TO_WIN, FALTA_ENVIDO_WINS_GAME_IN_MALAS:
+	default:
+		return fmt.Errorf("%w: unknown falta envido rule [%v]", ErrInvalidRules, r.FaltaEnvido)
+	}
+	switch r.Mazo {
+	case "", MAZO_ENVIDO_POINT, MAZO_MANO_BEFORE_FIRST_CARD, MAZO_ENVIDO_CLAIMABLE:
+	default:
+		return fmt.Errorf("%w: unknown mazo rule [%v]", ErrInvalidRules, r.Mazo)
+	}
+	if r.IsContraflorDisabled && !r.IsFlorEnabled {
+		return fmt.Errorf("%w: contraflor can only be disabled if flor is enabled", ErrInvalidRules)
+	}
+	if r.IsEnvidoKeptAfterFlor && !r.IsFlorEnabled {
+		return fmt.Errorf("%w: envido can only be kept after flor if flor is enabled", ErrInvalidRules)
+	}
+	if (r.PicaPicaFrom != 0 || r.PicaPicaTo != 0) && (r.PicaPicaFrom < 0 || r.PicaPicaTo <= r.PicaPicaFrom) {
+		return fmt.Errorf("%w: pica-pica range from %v to %v", ErrInvalidRules, r.PicaPicaFrom, r.PicaPicaTo)
+	}
+	if r.PicaPicaTo > r.maxPoints() {
+		return fmt.Errorf("%w: pica-pica range goes beyond max points", ErrInvalidRules)
+	}
+	return nil
+}
+
+func (r Rules) maxPoints() int {
+	if r.MaxPoints == 0 {
+		return DefaultMaxPoints
+	}
+	return r.MaxPoints
+}
+
+// MalasPoints are the points that split the game into "malas" and "buenas", e.g. 15 in a game
+// of 30 points. It's 0 in games of 15 points or less, since they have no malas.
+func (r Rules) MalasPoints() int {
+	if r.maxPoints() <= 15 {
+		return 0
+	}
+	return r.maxPoints() / 2
+}
+
+// StageOf returns the stage of a team with the given score: STAGE_MALAS until it reaches
+// MalasPoints, and STAGE_BUENAS from then on.
+func (r Rules) StageOf(score int) string {
+	if score < r.MalasPoints() {
+		return STAGE_MALAS
+	}
+	return STAGE_BUENAS
+}
+
+// withDefaults fills in the rules that are empty when they have their default value.
+func (r Rules) withDefaults() Rules {
+	r.MaxPoints = r.maxPoints()
+	if r.FaltaEnvido == "" {
+		r.FaltaEnvido = FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS
+	}
+	if r.Mazo == "" {
+		r.Mazo = MAZO_ENVIDO_POINT
+	}
+	return r
+}
+
+// WithRules sets all the rules of the game. It panics if they are invalid (see Rules.Validate).
+func WithRules(rules Rules) func(*GameState) {
+	return func(gs *GameState) {
+		if err := rules.Validate(); err != nil {
+			panic(err)
+		}
+		gs.Rules = rules
+	}
+}
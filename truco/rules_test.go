@@ -0,0 +1,246 @@
+package truco
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   Rules
+		isValid bool
+	}{
+		{name: "standard rules", rules: Rules{}, isValid: true},
+		{name: "every variant", rules: Rules{MaxPoints: 30, IsFlorEnabled: true, IsContraflorDisabled: true, IsEnvidoKeptAfterFlor: true, IsRealEnvidoRepeatable: true, IsEnvidoAllowedInSecondFaceoff: true, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_WIN}, isValid: true},
+		{name: "negative max points", rules: Rules{MaxPoints: -1}},
+		{name: "unknown falta envido rule", rules: Rules{FaltaEnvido: "half"}},
+		{name: "contraflor disabled without flor", rules: Rules{IsContraflorDisabled: true}},
+		{name: "envido kept after flor without flor", rules: Rules{IsEnvidoKeptAfterFlor: true}},
+		{name: "empty pica-pica range", rules: Rules{PicaPicaFrom: 5, PicaPicaTo: 5}},
+		{name: "pica-pica range beyond max points", rules: Rules{MaxPoints: 15, PicaPicaFrom: 5, PicaPicaTo: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.isValid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidRules)
+				require.Panics(t, func() { New(WithRules(tt.rules)) })
+			}
+		})
+	}
+}
+
+func TestFaltaEnvidoRule(t *testing.T) {
+	tests := []struct {
+		name                    string
+		rules                   Rules
+		winnerScore, loserScore int
+		expected                int
+	}{
+		{name: "to leave malas, both in malas", rules: Rules{MaxPoints: 30}, winnerScore: 3, loserScore: 10, expected: 12},
+		{name: "to leave malas, one in buenas", rules: Rules{MaxPoints: 30}, winnerScore: 3, loserScore: 20, expected: 10},
+		{name: "to win, both in malas", rules: Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_WIN}, winnerScore: 3, loserScore: 10, expected: 20},
+		{name: "to win, 15 point game", rules: Rules{MaxPoints: 15, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_WIN}, winnerScore: 3, loserScore: 10, expected: 5},
+		{name: "wins game in malas, both in malas", rules: Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_WINS_GAME_IN_MALAS}, winnerScore: 3, loserScore: 10, expected: 27},
+		{name: "wins game in malas, one in buenas", rules: Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_WINS_GAME_IN_MALAS}, winnerScore: 20, loserScore: 3, expected: 10},
+		{name: "wins game in malas, 15 point game", rules: Rules{MaxPoints: 15, FaltaEnvido: FALTA_ENVIDO_WINS_GAME_IN_MALAS}, winnerScore: 3, loserScore: 10, expected: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq := EnvidoSequence{Sequence: []string{SAY_FALTA_ENVIDO, SAY_ENVIDO_QUIERO}}
+			cost, err := seq.Cost(tt.rules, tt.winnerScore, tt.loserScore, true)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cost)
+		})
+	}
+}
+
+func TestFaltaEnvidoRuleAfterSonBuenas(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		expected int
+	}{
+		{name: "to leave malas", rules: Rules{MaxPoints: 30}, expected: 13},
+		{name: "to win", rules: Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_WIN}, expected: 20},
+		{name: "wins game in malas", rules: Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_WINS_GAME_IN_MALAS}, expected: 28},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameState := New(WithRules(tt.rules), WithDealer(dealerWithHands(map[int][]Card{
+				0: {{Suit: ESPADA, Number: 7}, {Suit: ESPADA, Number: 5}, {Suit: ORO, Number: 4}}, // 32
+				1: {{Suit: BASTO, Number: 2}, {Suit: COPA, Number: 10}, {Suit: ORO, Number: 12}},  // 2
+			})))
+			gameState.Players[0].Score = 2
+			gameState.Players[1].Score = 10
+
+			// Player 1 concedes, so the falta envido is worth what player 0, the winner, needs.
+			require.NoError(t, gameState.RunAction(NewActionSayFaltaEnvido(0)))
+			require.NoError(t, gameState.RunAction(NewActionSayEnvidoQuiero(1)))
+			sayScore := NewActionSayEnvidoScore(0)
+			sayScore.Enrich(*gameState)
+			require.NoError(t, gameState.RunAction(sayScore))
+			require.NoError(t, gameState.RunAction(NewActionSaySonBuenas(1)))
+			require.Equal(t, 0, gameState.RoundsLog[1].EnvidoWinnerPlayerID)
+			require.Equal(t, tt.expected, gameState.RoundsLog[1].EnvidoPoints)
+
+			// They're awarded once player 0 shows their cards, right away if they win the game.
+			if 2+tt.expected < tt.rules.MaxPoints {
+				require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+			}
+			require.NoError(t, gameState.RunAction(NewActionRevealEnvidoScore(0)))
+			require.Equal(t, 2+tt.expected, gameState.Players[0].Score)
+		})
+	}
+}
+
+func TestStages(t *testing.T) {
+	require.Equal(t, 15, Rules{MaxPoints: 30}.MalasPoints())
+	require.Equal(t, 0, Rules{MaxPoints: 15}.MalasPoints())
+	require.Equal(t, STAGE_MALAS, Rules{MaxPoints: 30}.StageOf(14))
+	require.Equal(t, STAGE_BUENAS, Rules{MaxPoints: 30}.StageOf(15))
+	require.Equal(t, STAGE_BUENAS, Rules{MaxPoints: 15}.StageOf(0))
+
+	gameState := New(WithRules(Rules{MaxPoints: 30, FaltaEnvido: FALTA_ENVIDO_WINS_GAME_IN_MALAS}))
+	require.Equal(t, STAGE_MALAS, gameState.Players[0].Stage)
+	require.Equal(t, STAGE_MALAS, gameState.Players[1].Stage)
+
+	// The falta envido wins the game while both are in malas.
+	gameState.Players[0].Score = 10
+	sayFaltaEnvido := NewActionSayFaltaEnvido(0)
+	sayFaltaEnvido.Enrich(*gameState)
+	require.Equal(t, 20, sayFaltaEnvido.(*ActionSayFaltaEnvido).QuieroCost)
+
+	// Once a team gets to buenas, its stage changes, and the falta envido is worth what the leader needs to win.
+	gameState.Players[1].Score = 13
+	require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+	require.Equal(t, STAGE_MALAS, gameState.Players[0].Stage)
+	require.Equal(t, STAGE_BUENAS, gameState.Players[1].Stage)
+	clientGameState := gameState.ToClientGameState(0)
+	require.Equal(t, STAGE_MALAS, clientGameState.YourStage)
+	require.Equal(t, STAGE_BUENAS, clientGameState.TheirStage)
+	require.Equal(t, STAGE_BUENAS, clientGameState.Players[1].Stage)
+
+	confirmAll(t, gameState)
+	sayFaltaEnvido = NewActionSayFaltaEnvido(1)
+	sayFaltaEnvido.Enrich(*gameState)
+	require.Equal(t, 15, sayFaltaEnvido.(*ActionSayFaltaEnvido).QuieroCost)
+}
+
+func TestRealEnvidoRepeatableRule(t *testing.T) {
+	for _, isRepeatable := range []bool{false, true} {
+		gameState := New(WithRules(Rules{IsRealEnvidoRepeatable: isRepeatable}))
+		require.NoError(t, gameState.RunAction(NewActionSayRealEnvido(0)))
+		require.Equal(t, isRepeatable, NewActionSayRealEnvido(1).IsPossible(*gameState))
+		if !isRepeatable {
+			continue
+		}
+		require.NoError(t, gameState.RunAction(NewActionSayRealEnvido(1)))
+		require.False(t, NewActionSayRealEnvido(0).IsPossible(*gameState))
+		require.True(t, NewActionSayFaltaEnvido(0).IsPossible(*gameState))
+
+		sayNoQuiero := NewActionSayEnvidoNoQuiero(0)
+		sayNoQuiero.Enrich(*gameState)
+		require.NoError(t, gameState.RunAction(sayNoQuiero))
+		require.Equal(t, 3, gameState.Players[1].Score)
+	}
+}
+
+func TestEnvidoAllowedInSecondFaceoffRule(t *testing.T) {
+	for _, isAllowed := range []bool{false, true} {
+		gameState := New(WithRules(Rules{IsEnvidoAllowedInSecondFaceoff: isAllowed}), WithDealer(dealerWithHands(map[int][]Card{
+			0: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: ESPADA, Number: 6}},
+			1: {{Suit: ORO, Number: 7}, {Suit: COPA, Number: 1}, {Suit: BASTO, Number: 11}},
+		})))
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: COPA, Number: 4}, 0)))
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: ORO, Number: 7}, 1)))
+		require.Equal(t, isAllowed, NewActionSayEnvido(1).IsPossible(*gameState))
+		require.Equal(t, isAllowed, !gameState.IsEnvidoFinished)
+
+		// Either way, it's over once the second faceoff is.
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: COPA, Number: 1}, 1)))
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: ORO, Number: 5}, 0)))
+		require.True(t, gameState.IsEnvidoFinished)
+	}
+}
+
+func TestFlorRules(t *testing.T) {
+	bothHaveFlor := dealerWithHands(map[int][]Card{
+		0: {{Suit: ESPADA, Number: 1}, {Suit: ESPADA, Number: 2}, {Suit: ESPADA, Number: 3}},
+		1: {{Suit: ORO, Number: 4}, {Suit: ORO, Number: 5}, {Suit: ORO, Number: 6}},
+	})
+	onlyManoHasFlor := dealerWithHands(map[int][]Card{
+		0: {{Suit: ESPADA, Number: 1}, {Suit: ESPADA, Number: 2}, {Suit: ESPADA, Number: 3}},
+		1: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: BASTO, Number: 6}},
+	})
+
+	t.Run("contraflor disabled", func(t *testing.T) {
+		for _, isDisabled := range []bool{false, true} {
+			gameState := New(WithRules(Rules{IsFlorEnabled: true, IsContraflorDisabled: isDisabled}), WithDealer(bothHaveFlor))
+			require.NoError(t, gameState.RunAction(NewActionSayFlor(0)))
+			require.Equal(t, !isDisabled, NewActionSayContraflor(1).IsPossible(*gameState))
+			require.Equal(t, !isDisabled, NewActionSayContraflorAlResto(1).IsPossible(*gameState))
+			require.True(t, NewActionSayConFlorQuiero(1).IsPossible(*gameState))
+			require.True(t, NewActionSayConFlorMeAchico(1).IsPossible(*gameState))
+		}
+	})
+
+	t.Run("envido kept after flor", func(t *testing.T) {
+		for _, isKept := range []bool{false, true} {
+			gameState := New(WithRules(Rules{IsFlorEnabled: true, IsEnvidoKeptAfterFlor: isKept}), WithDealer(onlyManoHasFlor))
+			require.NoError(t, gameState.RunAction(NewActionSayFlor(0)))
+			require.Equal(t, 3, gameState.RoundsLog[1].FlorPoints)
+			require.Equal(t, !isKept, gameState.IsEnvidoFinished)
+			require.Equal(t, isKept, NewActionSayEnvido(1).IsPossible(*gameState))
+		}
+	})
+}
+
+func TestTeamGamesDisableFlorRules(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithRules(Rules{IsFlorEnabled: true, IsContraflorDisabled: true, IsRealEnvidoRepeatable: true}))
+	require.Equal(t, Rules{MaxPoints: DefaultMaxPoints, IsRealEnvidoRepeatable: true, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS, Mazo: MAZO_ENVIDO_POINT}, gameState.Rules)
+	require.Equal(t, gameState.Rules, gameState.ToClientGameState(0).Rules)
+}
+
+func TestLegacyRulesAreMigrated(t *testing.T) {
+	var serialized map[string]any
+	require.NoError(t, json.Unmarshal([]byte(serializeForTest(t, New())), &serialized))
+	delete(serialized, "rules")
+	serialized["ruleMaxPoints"] = 15
+	serialized["ruleIsFlorEnabled"] = true
+	bs, err := json.Marshal(serialized)
+	require.NoError(t, err)
+
+	gameState, err := DeserializeGameState(bs)
+	require.NoError(t, err)
+	require.Equal(t, Rules{MaxPoints: 15, IsFlorEnabled: true, FaltaEnvido: FALTA_ENVIDO_POINTS_TO_LEAVE_MALAS, Mazo: MAZO_ENVIDO_POINT}, gameState.Rules)
+	require.Equal(t, 15, gameState.ToClientGameState(0).RuleMaxPoints)
+	require.True(t, gameState.ToClientGameState(0).RuleIsFlorEnabled)
+}
+
+func TestGamesWithRuleVariantsPlayToTheEnd(t *testing.T) {
+	for seed := int64(0); seed < 30; seed++ {
+		rules := Rules{
+			MaxPoints:                      15 * (1 + int(seed%2)),
+			IsFlorEnabled:                  true,
+			IsContraflorDisabled:           seed%3 == 0,
+			IsEnvidoKeptAfterFlor:          seed%3 == 1,
+			IsRealEnvidoRepeatable:         true,
+			IsEnvidoAllowedInSecondFaceoff: true,
+			FaltaEnvido:                    FALTA_ENVIDO_POINTS_TO_WIN,
+			Mazo:                           []string{MAZO_ENVIDO_POINT, MAZO_MANO_BEFORE_FIRST_CARD, MAZO_ENVIDO_CLAIMABLE}[seed%3],
+			IsMazoForbiddenDuringEnvido:    seed%2 == 0,
+		}
+		gameState := playRandomGame(t, seed, WithRules(rules))
+		require.Equal(t, rules.MaxPoints, gameState.Players[gameState.WinnerPlayerID].Score)
+
+		restored, err := DeserializeGameState([]byte(serializeForTest(t, gameState)))
+		require.NoError(t, err)
+		require.Equal(t, gameState.Rules, restored.Rules)
+	}
+}
@@ -0,0 +1,255 @@
+package truco
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dealerWithHands(hands map[int][]Card) Dealer {
+	return func(roundNumber int, playerID int) Hand {
+		return Hand{Unrevealed: hands[playerID]}
+	}
+}
+
+func TestTeamGameTurnOrder(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithDealer(dealerWithHands(map[int][]Card{
+		0: {{Suit: COPA, Number: 4}, {Suit: COPA, Number: 5}, {Suit: COPA, Number: 6}},
+		1: {{Suit: ORO, Number: 3}, {Suit: ORO, Number: 12}, {Suit: ORO, Number: 11}},
+		2: {{Suit: ESPADA, Number: 1}, {Suit: ORO, Number: 4}, {Suit: ORO, Number: 5}},
+		3: {{Suit: BASTO, Number: 2}, {Suit: BASTO, Number: 10}, {Suit: BASTO, Number: 11}},
+	})))
+	require.Equal(t, 0, gameState.RoundTurnPlayerID)
+
+	reveals := []struct {
+		playerID           int
+		card               Card
+		expectedTurnPlayer int
+	}{
+		// First faceoff: mano starts, and player 1's 3 wins it.
+		{0, Card{Suit: COPA, Number: 4}, 1},
+		{1, Card{Suit: ORO, Number: 3}, 2},
+		{2, Card{Suit: ORO, Number: 4}, 3},
+		{3, Card{Suit: BASTO, Number: 10}, 1},
+		// Second faceoff: the winner starts, and player 2's ancho wins it.
+		{1, Card{Suit: ORO, Number: 12}, 2},
+		{2, Card{Suit: ESPADA, Number: 1}, 3},
+		{3, Card{Suit: BASTO, Number: 11}, 0},
+		{0, Card{Suit: COPA, Number: 5}, 2},
+		// Third faceoff: player 3's 2 wins it, so their team wins the round.
+		{2, Card{Suit: ORO, Number: 5}, 3},
+		{3, Card{Suit: BASTO, Number: 2}, 0},
+		{0, Card{Suit: COPA, Number: 6}, 1},
+	}
+	for _, reveal := range reveals {
+		require.Equal(t, reveal.playerID, gameState.TurnPlayerID)
+		require.NoError(t, gameState.RunAction(NewActionRevealCard(reveal.card, reveal.playerID)))
+		require.Equal(t, reveal.expectedTurnPlayer, gameState.TurnPlayerID)
+	}
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(Card{Suit: ORO, Number: 11}, 1)))
+
+	require.True(t, gameState.IsRoundFinished)
+	require.Equal(t, []int{1, 2, 3}, gameState.CardRevealSequence.BistepWinners)
+	require.Equal(t, 1, gameState.RoundsLog[1].TrucoWinnerPlayerID)
+	for playerID, expectedScore := range []int{0, 1, 0, 1} {
+		require.Equal(t, expectedScore, gameState.Players[playerID].Score)
+	}
+
+	// All players must confirm the round finished, and then the next player is mano.
+	for playerID := 0; playerID < 4; playerID++ {
+		require.NoError(t, gameState.RunAction(NewActionConfirmRoundFinished(gameState.TurnPlayerID)))
+	}
+	require.Equal(t, 2, gameState.RoundNumber)
+	require.Equal(t, 1, gameState.RoundTurnPlayerID)
+	require.Equal(t, 1, gameState.TurnPlayerID)
+}
+
+func TestFaceoffWinnerPlayerID(t *testing.T) {
+	step := func(playerID int, card Card) CardRevealSequenceStep {
+		return CardRevealSequenceStep{card: card, playerID: playerID}
+	}
+	tests := []struct {
+		name     string
+		steps    []CardRevealSequenceStep
+		expected int
+	}{
+		{
+			name:     "highest card wins",
+			steps:    []CardRevealSequenceStep{step(0, Card{Suit: ORO, Number: 3}), step(1, Card{Suit: ESPADA, Number: 7})},
+			expected: 1,
+		},
+		{
+			name:     "tie between opponents is parda",
+			steps:    []CardRevealSequenceStep{step(0, Card{Suit: ORO, Number: 3}), step(1, Card{Suit: COPA, Number: 3})},
+			expected: -1,
+		},
+		{
+			name: "tie between teammates goes to the first of them",
+			steps: []CardRevealSequenceStep{
+				step(2, Card{Suit: ORO, Number: 3}), step(3, Card{Suit: COPA, Number: 4}),
+				step(0, Card{Suit: COPA, Number: 3}), step(1, Card{Suit: ORO, Number: 5}),
+			},
+			expected: 2,
+		},
+		{
+			name: "higher card breaks a tie",
+			steps: []CardRevealSequenceStep{
+				step(0, Card{Suit: ORO, Number: 3}), step(1, Card{Suit: COPA, Number: 3}),
+				step(2, Card{Suit: ESPADA, Number: 7}), step(3, Card{Suit: ORO, Number: 5}),
+			},
+			expected: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, faceoffWinnerPlayerID(tt.steps))
+		})
+	}
+}
+
+func TestTeamEnvido(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithDealer(dealerWithHands(map[int][]Card{
+		0: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: ESPADA, Number: 6}},   // 6
+		1: {{Suit: ORO, Number: 7}, {Suit: ORO, Number: 1}, {Suit: BASTO, Number: 11}},    // 28
+		2: {{Suit: ESPADA, Number: 7}, {Suit: ESPADA, Number: 5}, {Suit: ORO, Number: 4}}, // 32
+		3: {{Suit: BASTO, Number: 2}, {Suit: COPA, Number: 10}, {Suit: ORO, Number: 12}},  // 2
+	})))
+
+	// Player 1 answers player 0's envido, and mano says their own score, since player 2's is hidden
+	// from them.
+	require.NoError(t, gameState.RunAction(NewActionSayEnvido(0)))
+	require.Equal(t, 1, gameState.TurnPlayerID)
+	require.NoError(t, gameState.RunAction(NewActionSayEnvidoQuiero(1)))
+	require.Equal(t, 0, gameState.TurnPlayerID)
+	var sayScore Action
+	for _, a := range gameState.ToClientGameState(0).PossibleActions {
+		if action, _ := DeserializeAction(a); action.GetName() == SAY_ENVIDO_SCORE {
+			sayScore = action
+		}
+	}
+	require.Equal(t, 6, sayScore.(*ActionSayEnvidoScore).Score)
+	require.NoError(t, gameState.RunAction(sayScore))
+
+	// Player 1's team can't beat the team's best score, which is player 2's.
+	require.Equal(t, 1, gameState.TurnPlayerID)
+	require.False(t, NewActionSaySonMejores(1).IsPossible(*gameState))
+	require.NoError(t, gameState.RunAction(NewActionSaySonBuenas(1)))
+	require.Equal(t, 2, gameState.RoundsLog[1].EnvidoWinnerPlayerID)
+	require.Equal(t, 0, gameState.TurnPlayerID)
+
+	// Points are awarded once player 2 shows their cards.
+	require.Equal(t, 0, gameState.Players[0].Score)
+	require.NoError(t, gameState.RunAction(NewActionSayMeVoyAlMazo(0)))
+	require.True(t, NewActionRevealEnvidoScore(2).IsPossible(*gameState))
+	require.False(t, NewActionConfirmRoundFinished(2).IsPossible(*gameState))
+	require.NoError(t, gameState.RunAction(NewActionRevealEnvidoScore(2)))
+	require.Equal(t, 2, gameState.Players[0].Score)
+	require.Equal(t, 2, gameState.Players[2].Score)
+	require.Equal(t, 1, gameState.Players[1].Score) // the mazo costs 1, since envido was played
+}
+
+func TestTeamTruco(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithSeed(1))
+
+	// Player 1 says truco on their turn, player 2 accepts, and it's player 1's turn again.
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[0].Hand.Unrevealed[0], 0)))
+	require.NoError(t, gameState.RunAction(NewActionSayTruco(1)))
+	require.Equal(t, 2, gameState.TurnPlayerID)
+	require.NoError(t, gameState.RunAction(NewActionSayTrucoQuiero(2)))
+	require.Equal(t, 1, gameState.TurnPlayerID)
+
+	// Player 1's team can't raise the stakes, but player 2's team can.
+	require.False(t, NewActionSayQuieroRetruco(1).IsPossible(*gameState))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[1].Hand.Unrevealed[0], 1)))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[2].Hand.Unrevealed[0], 2)))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[3].Hand.Unrevealed[0], 3)))
+	require.True(t, NewActionSayQuieroRetruco(gameState.TurnPlayerID).IsPossible(*gameState) == (gameState.TeamOf(gameState.TurnPlayerID) == 0))
+}
+
+func TestTurnOpponentInTeamGames(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithDealer(dealerWithHands(map[int][]Card{
+		0: {{Suit: COPA, Number: 4}, {Suit: ORO, Number: 5}, {Suit: ESPADA, Number: 6}},
+		1: {{Suit: ORO, Number: 7}, {Suit: ORO, Number: 1}, {Suit: BASTO, Number: 11}},
+		2: {{Suit: ESPADA, Number: 7}, {Suit: ESPADA, Number: 5}, {Suit: ORO, Number: 4}},
+		3: {{Suit: BASTO, Number: 2}, {Suit: COPA, Number: 10}, {Suit: ORO, Number: 12}},
+	})))
+	requireTurnOpponent := func() {
+		require.Equal(t, gameState.OpponentOf(gameState.TurnPlayerID), gameState.TurnOpponentPlayerID)
+		require.NotEqual(t, gameState.TeamOf(gameState.TurnPlayerID), gameState.TeamOf(gameState.TurnOpponentPlayerID))
+	}
+
+	// Player 1 answers player 0's envido, but their opponent is still the next player.
+	require.NoError(t, gameState.RunAction(NewActionSayEnvido(0)))
+	require.Equal(t, 1, gameState.TurnPlayerID)
+	require.Equal(t, 0, gameState.AnsweredPlayerID)
+	requireTurnOpponent()
+
+	// Player 1's raise goes back to player 0.
+	require.NoError(t, gameState.RunAction(NewActionSayRealEnvido(1)))
+	require.Equal(t, 0, gameState.TurnPlayerID)
+	require.Equal(t, 1, gameState.AnsweredPlayerID)
+	requireTurnOpponent()
+
+	r := rand.New(rand.NewSource(1))
+	for turns := 0; turns < 200 && !gameState.IsGameEnded; turns++ {
+		actions := gameState.CalculatePossibleActions()
+		err := gameState.RunAction(actions[r.Intn(len(actions))])
+		if errors.Is(err, ErrNotYourTurn) {
+			continue
+		}
+		require.NoError(t, err)
+		requireTurnOpponent()
+	}
+}
+
+func TestLegacyTurnOpponentIsMigrated(t *testing.T) {
+	gameState := New(WithPlayerCount(4))
+	require.NoError(t, gameState.RunAction(NewActionSayEnvido(0)))
+
+	// Older game states have the player being answered as the turn opponent.
+	var serialized map[string]any
+	require.NoError(t, json.Unmarshal([]byte(serializeForTest(t, gameState)), &serialized))
+	delete(serialized, "answeredPlayerID")
+	serialized["turnOpponentPlayerID"] = 0
+	bs, err := json.Marshal(serialized)
+	require.NoError(t, err)
+
+	restored, err := DeserializeGameState(bs)
+	require.NoError(t, err)
+	require.Equal(t, 2, restored.TurnOpponentPlayerID)
+	require.Equal(t, 0, restored.AnsweredPlayerID)
+	require.NoError(t, restored.RunAction(NewActionSayRealEnvido(1)))
+	require.Equal(t, 0, restored.TurnPlayerID)
+}
+
+func TestTeamGamesPlayToTheEnd(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		gameState := playRandomGame(t, seed, WithPlayerCount(4), WithMaxPoints(15))
+		require.Equal(t, 15, gameState.Players[gameState.WinnerPlayerID].Score)
+		for playerID := 0; playerID < 4; playerID++ {
+			require.Equal(t, gameState.Players[playerID%2].Score, gameState.Players[playerID].Score)
+		}
+
+		restored, err := DeserializeGameState([]byte(serializeForTest(t, gameState)))
+		require.NoError(t, err)
+		require.Equal(t, gameState.ToClientGameState(3), restored.ToClientGameState(3))
+	}
+}
+
+func TestClientGameStateHasAllPlayers(t *testing.T) {
+	gameState := New(WithPlayerCount(4))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[0].Hand.Unrevealed[0], 0)))
+
+	clientGameState := gameState.ToClientGameState(2)
+	require.Equal(t, 0, clientGameState.YourTeamID)
+	require.Len(t, clientGameState.Players, 4)
+	require.Equal(t, gameState.Players[0].Hand.Revealed, clientGameState.Players[0].RevealedCards)
+	for _, player := range clientGameState.Players {
+		for _, card := range player.DisplayUnrevealedCards {
+			require.Equal(t, player.PlayerID != 2 && !card.IsHole, card.IsBackwards)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 )
 
 // DefaultMaxPoints is the points a player must reach to win the game.
@@ -25,14 +26,37 @@ type GameState struct {
 	// They are the same at the beginning of the round.
 	TurnPlayerID int `json:"turnPlayerID"`
 
-	// TurnOpponentPlayerID is the player ID of the opponent of the player whose turn it is.
+	// TurnOpponentPlayerID is the player ID of the opponent of the player whose turn it is, i.e.
+	// OpponentOf(TurnPlayerID).
 	TurnOpponentPlayerID int `json:"turnOpponentPlayerID"`
 
+	// AnsweredPlayerID is the player ID of the player whose action (e.g. a bet) the player whose
+	// turn it is must answer, or -1 if they aren't answering anyone. In team games, it's often not
+	// TurnOpponentPlayerID.
+	AnsweredPlayerID int `json:"answeredPlayerID"`
+
 	// Players is a map of player IDs to their respective hands and scores.
-	// There are 2 players in a game. Use TurnPlayerID and TurnOpponentPlayerID to index
-	// into this map, or iterate over it to discover player ids.
+	// There are 2 players in a game, or 4 or 6 in a team game (see WithPlayerCount). Player IDs go
+	// from 0 to the number of players minus one, in the order they are seated around the table.
+	// Use TurnPlayerID and TurnOpponentPlayerID to index into this map, or iterate over it to
+	// discover player ids.
 	Players map[int]*Player `json:"players"`
 
+	// RoundType is ROUND_TYPE_NORMAL, or ROUND_TYPE_PICA_PICA in six-player games with WithPicaPica.
+	//
+	// A pica-pica round is made of three duels, played one after the other, in which each player
+	// plays their hand against the opponent seated across from them. Each duel is played as a
+	// round of its own (i.e. it has its own RoundNumber), but cards are only dealt for the first one.
+	RoundType string `json:"roundType"`
+
+	// PicaPicaDuel is the index of the current duel in a pica-pica round (from 0 to 2), or 0
+	// otherwise.
+	PicaPicaDuel int `json:"picaPicaDuel"`
+
+	// RoundPlayerIDs are the players who play the current round, in turn order starting from mano.
+	// In a normal round, it's all players. In a pica-pica duel, it's the two players in the duel.
+	RoundPlayerIDs []int `json:"roundPlayerIDs"`
+
 	// PossibleActions is a list of possible actions that the current player can take.
 	// Possible actions are calculated based on game state at the beginnin of the round and after
 	// each action is run (i.e. GameState.RunAction).
@@ -65,8 +89,7 @@ type GameState struct {
 	// for setting this. During `GameState.RunAction()`, If the action's `Run()` method sets this to true,
 	// then `GameState.startNewRound()` will be called.
 	//
-	// Clients are not really notified of a round change, so they should keep track of the "last round
-	// number" to see if it changes.
+	// Clients are notified of a round change with EVENT_ROUND_FINISHED and EVENT_ROUND_STARTED (see Events).
 	IsRoundFinished bool `json:"isRoundFinished"`
 
 	// IsGameEnded is true if the whole game is ended, rather than an individual round. This happens when
@@ -92,25 +115,64 @@ type GameState struct {
 
 	RoundFinishedConfirmedPlayerIDs map[int]bool `json:"roundFinishedConfirmedPlayerIDs"`
 
-	RuleMaxPoints     int  `json:"ruleMaxPoints"`
-	RuleIsFlorEnabled bool `json:"ruleIsFlorEnabled"`
+	// Events are the events that happened while running the last action (see Event), in the order
+	// they happened. RunAction replaces them on every action; after New, they have the first round's
+	// start.
+	Events []Event `json:"-"`
+
+	// Rules are the house rules of the game. Unlike the ones given to New, they have no empty
+	// values that mean "default", e.g. Rules.MaxPoints is always set.
+	Rules Rules `json:"rules"`
+
+	// Seed determines how cards are shuffled on every round, so a game can be reproduced from
+	// its seed and its actions. It's random unless set with WithSeed.
+	Seed int64 `json:"seed"`
 
-	deck *deck `json:"-"`
+	deck       *deck       `json:"-"`
+	randSource rand.Source `json:"-"`
+	dealer     Dealer      `json:"-"`
 }
 
+// Round types. See GameState.RoundType.
+const (
+	ROUND_TYPE_NORMAL    = "normal"
+	ROUND_TYPE_PICA_PICA = "pica_pica"
+)
+
+// Dealer returns the hand dealt to a player at the start of a round. See WithDealer.
+type Dealer func(roundNumber int, playerID int) Hand
+
 type Player struct {
 	// Hands contains the revealed and unrevealed cards of the player.
 	Hand *Hand `json:"hand"`
 
-	// Score is the player's scores (from 0 to MaxPoints).
+	// Score is the player's scores (from 0 to MaxPoints). In team games, it's the team's score,
+	// so it's the same for all players of a team.
 	Score int `json:"score"`
+
+	// Stage is STAGE_MALAS or STAGE_BUENAS, depending on the Score. See Rules.StageOf.
+	Stage string `json:"stage"`
 }
 
 // RoundLog is a log of a round that was played in the game
 type RoundLog struct {
-	// HandsDealt is a map from PlayerID to its hand during this round.
+	// HandsDealt is a map from PlayerID to the hand it was dealt at the start of this round.
+	// It's a copy, so it doesn't change as cards are revealed.
+	//
+	// In pica-pica rounds, all hands are dealt on the first duel, so only its log has all of them.
+	// The logs of the other duels have the hands of the duel's players.
 	HandsDealt map[int]*Hand `json:"handsDealt"`
 
+	// Type, PicaPicaDuel and PlayerIDs are GameState's RoundType, PicaPicaDuel and RoundPlayerIDs
+	// for this round.
+	Type         string `json:"type"`
+	PicaPicaDuel int    `json:"picaPicaDuel"`
+	PlayerIDs    []int  `json:"playerIDs"`
+
+	// TeamPoints are the points each team scored in this round, indexed by team (see GameState.TeamOf).
+	// To get the points of a whole pica-pica round, add up the TeamPoints of its duels.
+	TeamPoints [2]int `json:"teamPoints"`
+
 	// For envido/truco winners and points, note that there is still a
 	// winner of 1 point if a player said "no quiero" to the envido/truco.
 	//
@@ -126,8 +188,58 @@ type RoundLog struct {
 	TrucoWinnerPlayerID  int `json:"trucoWinnerPlayerID"`
 	TrucoPoints          int `json:"trucoPoints"`
 
+	// Mazo is the breakdown of the points of a "me voy al mazo", or nil if nobody went to the mazo.
+	Mazo *MazoLog `json:"mazo,omitempty"`
+
 	// ActionsLog is the ordered list of actions of this round.
 	ActionsLog []ActionLog `json:"actionsLog"`
+
+	// ScoresLog is the ordered list of score changes of this round, which explains TeamPoints.
+	ScoresLog []ScoreLog `json:"scoresLog"`
+
+	// RevealedCards are the cards that each player of the round revealed, by player ID. It's set
+	// when the next round starts, so it's nil for the current round.
+	RevealedCards map[int][]Card `json:"revealedCards,omitempty"`
+}
+
+// ScoreLog is a log of points awarded to a team in a round.
+type ScoreLog struct {
+	// Reason is why the points were awarded: one of the POINTS_REASON_* constants.
+	Reason string `json:"reason"`
+
+	// PlayerID is the player who won the points, e.g. the envido winner. Their whole team scores them.
+	PlayerID int `json:"playerID"`
+	TeamID   int `json:"teamID"`
+	Points   int `json:"points"`
+
+	// ScoreBefore and ScoreAfter are the team's score before and after the points were awarded.
+	// ScoreAfter is never above Rules.MaxPoints.
+	ScoreBefore int `json:"scoreBefore"`
+	ScoreAfter  int `json:"scoreAfter"`
+
+	// ActionPlayerID and Action are the player and the JSON-serialized action that triggered the
+	// points, like in ActionLog. It's not always the winner's action, e.g. a "no quiero" awards
+	// points to the opponent, and revealing a card may award the envido points.
+	ActionPlayerID int             `json:"actionPlayerID"`
+	Action         json.RawMessage `json:"action"`
+}
+
+// MazoLog is the breakdown of the points awarded when a player goes to the mazo. They are all
+// awarded to the opponent, and their total is logged as the round's TrucoPoints.
+type MazoLog struct {
+	// PlayerID is the player who went to the mazo.
+	PlayerID int `json:"playerID"`
+
+	// TrucoPoints are the points of the truco, as if it was lost.
+	TrucoPoints int `json:"trucoPoints"`
+
+	// EnvidoPoints are the points for the envido that can't be played anymore. See Rules.Mazo.
+	EnvidoPoints int `json:"envidoPoints"`
+
+	// IsEnvidoClaimed is true if the opponent claimed the envido (see MAZO_ENVIDO_CLAIMABLE), i.e.
+	// their team had the better envido score. The cards that show it are revealed, and its points
+	// are the EnvidoPoints.
+	IsEnvidoClaimed bool `json:"isEnvidoClaimed"`
 }
 
 // ActionLog is a log of an action that was run in a round.
@@ -143,36 +255,102 @@ type ActionLog struct {
 // WithMaxPoints sets the maximum points required to win the game.
 func WithMaxPoints(maxPoints int) func(*GameState) {
 	return func(gs *GameState) {
-		gs.RuleMaxPoints = maxPoints
+		gs.Rules.MaxPoints = maxPoints
 	}
 }
 
 // WithFlorEnabled sets whether the "flor" rule is enabled.
 func WithFlorEnabled(isFlorEnabled bool) func(*GameState) {
 	return func(gs *GameState) {
-		gs.RuleIsFlorEnabled = isFlorEnabled
+		gs.Rules.IsFlorEnabled = isFlorEnabled
+	}
+}
+
+// WithPlayerCount sets the number of players, which must be 2, 4 (i.e. 2 vs 2) or 6 (i.e. 3 vs 3).
+//
+// Players are seated around the table in player ID order, and alternate teams: even player IDs
+// are one team, and odd ones are the other (see TeamOf). Flor is only supported for 2 players,
+// so it's disabled in team games regardless of WithFlorEnabled.
+func WithPlayerCount(playerCount int) func(*GameState) {
+	return func(gs *GameState) {
+		if playerCount != 2 && playerCount != 4 && playerCount != 6 {
+			panic(fmt.Sprintf("unsupported player count: %v", playerCount))
+		}
+		gs.Players = map[int]*Player{}
+		for playerID := 0; playerID < playerCount; playerID++ {
+			gs.Players[playerID] = &Player{Hand: nil, Score: 0}
+		}
+	}
+}
+
+// WithPicaPica enables pica-pica rounds in six-player games, while the leading team has at least
+// `from` points and less than `to` points. In that range, pica-pica rounds alternate with normal ones.
+//
+// It's ignored in games with fewer players, since there is nobody seated across from each player.
+func WithPicaPica(from int, to int) func(*GameState) {
+	return func(gs *GameState) {
+		if from < 0 || to <= from {
+			panic(fmt.Sprintf("invalid pica-pica range: from %v to %v", from, to))
+		}
+		gs.Rules.PicaPicaFrom = from
+		gs.Rules.PicaPicaTo = to
+	}
+}
+
+// WithSeed sets the seed that determines how cards are shuffled on every round. Two games
+// with the same seed and the same actions are identical.
+func WithSeed(seed int64) func(*GameState) {
+	return func(gs *GameState) {
+		gs.Seed = seed
+	}
+}
+
+// WithRandSource shuffles cards with the given source instead of deriving it from the seed.
+//
+// Note that the source can't be serialized, so a deserialized GameState goes back to shuffling
+// based on its seed.
+func WithRandSource(source rand.Source) func(*GameState) {
+	return func(gs *GameState) {
+		gs.randSource = source
+	}
+}
+
+// WithDealer deals hands with the given function instead of shuffling cards, e.g. to test
+// specific hands. It's called for every player (2, 4 or 6 of them) at the start of every round.
+//
+// Note that the dealer can't be serialized, so a deserialized GameState goes back to shuffling.
+func WithDealer(dealer Dealer) func(*GameState) {
+	return func(gs *GameState) {
+		gs.dealer = dealer
 	}
 }
 
 func New(opts ...func(*GameState)) *GameState {
 	gs := &GameState{
-		RoundTurnPlayerID: 1,
+		RoundTurnPlayerID: -1, // so that the first round's mano is player 0
 		RoundNumber:       0,
 		Players: map[int]*Player{
 			0: {Hand: nil, Score: 0},
 			1: {Hand: nil, Score: 0},
 		},
-		IsGameEnded:       false,
-		WinnerPlayerID:    -1,
-		RoundsLog:         []*RoundLog{{}}, // initialised with an empty round to be 1-indexed
-		deck:              newDeck(),
-		RuleMaxPoints:     DefaultMaxPoints,
-		RuleIsFlorEnabled: false,
+		IsGameEnded:    false,
+		WinnerPlayerID: -1,
+		RoundsLog:      []*RoundLog{{}}, // initialised with an empty round to be 1-indexed
+		deck:           newDeck(),
+		Seed:           rand.Int63(),
 	}
 
 	for _, opt := range opts {
 		opt(gs)
 	}
+	gs.Rules = gs.Rules.withDefaults()
+	gs.updateStages()
+	if len(gs.Players) > 2 {
+		gs.Rules.IsFlorEnabled, gs.Rules.IsContraflorDisabled, gs.Rules.IsEnvidoKeptAfterFlor = false, false, false
+	}
+	if len(gs.Players) != 6 {
+		gs.Rules.PicaPicaFrom, gs.Rules.PicaPicaTo = 0, 0
+	}
 
 	gs.startNewRound()
 
@@ -180,25 +358,69 @@ func New(opts ...func(*GameState)) *GameState {
 }
 
 func (g *GameState) startNewRound() {
-	g.deck.shuffle()
-	g.RoundTurnPlayerID = g.OpponentOf(g.RoundTurnPlayerID)
+	if g.RoundNumber > 0 {
+		g.logRevealedCards()
+	}
 	g.RoundNumber++
-	g.TurnPlayerID = g.RoundTurnPlayerID
-	g.TurnOpponentPlayerID = g.OpponentOf(g.TurnPlayerID)
-	g.Players[g.TurnPlayerID].Hand = g.deck.dealHand()
-	g.Players[g.TurnOpponentPlayerID].Hand = g.deck.dealHand()
-	g.EnvidoSequence = &EnvidoSequence{StartingPlayerID: -1}
-	g.TrucoSequence = &TrucoSequence{StartingPlayerID: -1, QuieroOwnerPlayerID: -1}
+	isDealing := true
+	switch {
+	case g.RoundType == ROUND_TYPE_PICA_PICA && g.PicaPicaDuel < len(g.Players)/2-1:
+		// The next duel of the pica-pica round is started by the next player, with the cards already dealt.
+		g.PicaPicaDuel++
+		g.RoundTurnPlayerID = g.nextPlayerID(g.RoundTurnPlayerID)
+		isDealing = false
+	case g.RoundType != ROUND_TYPE_PICA_PICA && g.isPicaPicaScore():
+		g.RoundType = ROUND_TYPE_PICA_PICA
+		g.PicaPicaDuel = 0
+		g.RoundTurnPlayerID = g.nextPlayerID(g.RoundTurnPlayerID)
+	default:
+		if g.RoundType == ROUND_TYPE_PICA_PICA {
+			// Mano moves on from the player who started the pica-pica round's first duel.
+			g.RoundTurnPlayerID = ((g.RoundTurnPlayerID-g.PicaPicaDuel)%len(g.Players) + len(g.Players)) % len(g.Players)
+		}
+		g.RoundType = ROUND_TYPE_NORMAL
+		g.PicaPicaDuel = 0
+		g.RoundTurnPlayerID = g.nextPlayerID(g.RoundTurnPlayerID)
+	}
+
+	g.RoundPlayerIDs = []int{}
+	if g.RoundType == ROUND_TYPE_PICA_PICA {
+		g.RoundPlayerIDs = append(g.RoundPlayerIDs, g.RoundTurnPlayerID, (g.RoundTurnPlayerID+len(g.Players)/2)%len(g.Players))
+	} else {
+		for i := 0; i < len(g.Players); i++ {
+			g.RoundPlayerIDs = append(g.RoundPlayerIDs, (g.RoundTurnPlayerID+i)%len(g.Players))
+		}
+	}
+	g.setTurn(g.RoundTurnPlayerID)
+
+	handsDealt := map[int]*Hand{}
+	if isDealing {
+		g.deck.shuffle(g.roundRand())
+		for i := 0; i < len(g.Players); i++ {
+			// Cards are dealt starting from mano, around the table.
+			playerID := (g.RoundTurnPlayerID + i) % len(g.Players)
+			g.Players[playerID].Hand = g.dealHand(playerID)
+			hand := g.Players[playerID].Hand.DeepCopy()
+			handsDealt[playerID] = &hand
+		}
+	} else {
+		for _, playerID := range g.RoundPlayerIDs {
+			hand := g.Players[playerID].Hand.DeepCopy()
+			handsDealt[playerID] = &hand
+		}
+	}
+	g.EnvidoSequence = &EnvidoSequence{StartingPlayerID: -1, IsRealEnvidoRepeatable: g.Rules.IsRealEnvidoRepeatable}
+	g.TrucoSequence = &TrucoSequence{StartingPlayerID: -1, QuieroOwnerPlayerID: -1, AnsweringPlayerID: -1}
 	g.FlorSequence = &FlorSequence{StartingPlayerID: -1}
 	g.CardRevealSequence = &CardRevealSequence{}
 	g.IsEnvidoFinished = false
 	g.IsRoundFinished = false
 	g.RoundFinishedConfirmedPlayerIDs = map[int]bool{}
 	g.RoundsLog = append(g.RoundsLog, &RoundLog{
-		HandsDealt: map[int]*Hand{
-			g.TurnPlayerID:         g.Players[g.TurnPlayerID].Hand,
-			g.TurnOpponentPlayerID: g.Players[g.TurnOpponentPlayerID].Hand,
-		},
+		HandsDealt:           handsDealt,
+		Type:                 g.RoundType,
+		PicaPicaDuel:         g.PicaPicaDuel,
+		PlayerIDs:            _cloneSlice(g.RoundPlayerIDs),
 		EnvidoWinnerPlayerID: -1,
 		EnvidoPoints:         0,
 		TrucoWinnerPlayerID:  -1,
@@ -206,31 +428,81 @@ func (g *GameState) startNewRound() {
 		FlorWinnerPlayerID:   -1,
 		FlorPoints:           0,
 		ActionsLog:           []ActionLog{},
+		ScoresLog:            []ScoreLog{},
 	})
+	g.emit(&EventRoundStarted{evt: evt{Type: EVENT_ROUND_STARTED}, RoundNumber: g.RoundNumber, RoundTurnPlayerID: g.RoundTurnPlayerID, RoundType: g.RoundType, PicaPicaDuel: g.PicaPicaDuel})
 	g.PossibleActions = _serializeActions(g.CalculatePossibleActions())
 }
 
+// isPicaPicaScore returns whether the score is in the pica-pica range, i.e. whether the next
+// round should be a pica-pica round if the last one wasn't.
+func (g GameState) isPicaPicaScore() bool {
+	if g.Rules.PicaPicaTo == 0 {
+		return false
+	}
+	leadingScore := 0
+	for _, player := range g.Players {
+		leadingScore = max(leadingScore, player.Score)
+	}
+	return leadingScore >= g.Rules.PicaPicaFrom && leadingScore < g.Rules.PicaPicaTo
+}
+
+// roundRand returns the source of randomness to shuffle the cards of the current round.
+//
+// Each round's shuffle only depends on the seed and the round number, rather than on previous
+// shuffles, so that a deserialized GameState keeps dealing the same cards.
+func (g *GameState) roundRand() *rand.Rand {
+	if g.randSource != nil {
+		return rand.New(g.randSource)
+	}
+	return rand.New(rand.NewSource(g.Seed ^ int64(g.RoundNumber)*0x5DEECE66D))
+}
+
+func (g *GameState) dealHand(playerID int) *Hand {
+	if g.dealer == nil {
+		return g.deck.dealHand()
+	}
+	dealt := g.dealer(g.RoundNumber, playerID)
+	// Copy the cards, so that the dealer's hands don't change as cards are revealed.
+	hand := Hand{
+		Unrevealed: _cloneSlice(dealt.Unrevealed),
+		Revealed:   _cloneSlice(dealt.Revealed),
+	}
+	hand.initializeDisplayUnrevealedCards()
+	return &hand
+}
+
 func (g *GameState) RunAction(action Action) error {
 	if action == nil {
 		return nil
 	}
 
 	if g.IsGameEnded {
-		return fmt.Errorf("%w trying to run [%v]", errGameIsEnded, action)
+		return fmt.Errorf("%w trying to run [%v]", ErrGameIsEnded, action)
 	}
 
 	if !g.IsRoundFinished && action.GetPlayerID() != g.TurnPlayerID {
-		return errNotYourTurn
+		return ErrNotYourTurn
 	}
 
 	if !action.IsPossible(*g) {
-		return fmt.Errorf("%w trying to run [%v]", errActionNotPossible, action)
+		return fmt.Errorf("%w trying to run [%v]", ErrActionNotPossible, action)
 	}
+	g.Events = nil
+	wasRoundFinished := g.IsRoundFinished
+	scoresLogLen := len(g.RoundsLog[g.RoundNumber].ScoresLog)
 	err := action.Run(g)
 	if err != nil {
 		return fmt.Errorf("%w trying to run [%v] after checking it was possible", err, action)
 	}
 
+	// Points awarded while running the action are logged as triggered by it
+	scoresLog := g.RoundsLog[g.RoundNumber].ScoresLog
+	for i := scoresLogLen; i < len(scoresLog); i++ {
+		scoresLog[i].ActionPlayerID = action.GetPlayerID()
+		scoresLog[i].Action = SerializeAction(action)
+	}
+
 	if action.GetName() != CONFIRM_ROUND_FINISHED {
 		g.RoundsLog[g.RoundNumber].ActionsLog = append(g.RoundsLog[g.RoundNumber].ActionsLog, ActionLog{
 			PlayerID: g.TurnPlayerID,
@@ -238,8 +510,12 @@ func (g *GameState) RunAction(action Action) error {
 		})
 	}
 
+	if !wasRoundFinished && g.IsRoundFinished {
+		g.emit(&EventRoundFinished{evt: evt{Type: EVENT_ROUND_FINISHED}, RoundNumber: g.RoundNumber})
+	}
+
 	// Start new round if current round is finished
-	if !g.IsGameEnded && g.IsRoundFinished && len(g.RoundFinishedConfirmedPlayerIDs) == 2 {
+	if !g.IsGameEnded && g.IsRoundFinished && len(g.RoundFinishedConfirmedPlayerIDs) == len(g.Players) {
 		// fmt.Println("Starting new round...")
 		g.startNewRound()
 		return nil
@@ -247,28 +523,53 @@ func (g *GameState) RunAction(action Action) error {
 
 	// Switch player turn within current round (unless current action doesn't yield turn)
 	if !g.IsGameEnded && !g.IsRoundFinished && action.YieldsTurn(*g) {
-		g.TurnPlayerID, g.TurnOpponentPlayerID = g.TurnOpponentPlayerID, g.TurnPlayerID
+		if a, ok := action.(turnPasser); ok {
+			g.setTurn(a.nextTurnPlayerID(*g))
+		} else {
+			g.changeTurn()
+		}
 	}
 
-	if !g.IsGameEnded && g.IsRoundFinished && len(g.RoundFinishedConfirmedPlayerIDs) == 1 {
-		if g.RoundFinishedConfirmedPlayerIDs[g.TurnPlayerID] {
-			g.changeTurn()
+	// If an envido or flor interrupted a truco, the truco is resumed once they are over
+	if !g.IsGameEnded && !g.IsRoundFinished && g.TrucoSequence.IsPending() && !g.isEnvidoOrFlorInProgress() &&
+		g.TurnPlayerID != g.TrucoSequence.AnsweringPlayerID {
+		g.setTurn(g.TrucoSequence.AnsweringPlayerID)
+	}
+
+	// The turn goes to the next player who is left to confirm the round finished
+	if !g.IsGameEnded && g.IsRoundFinished && g.RoundFinishedConfirmedPlayerIDs[g.TurnPlayerID] {
+		for playerID := g.nextPlayerID(g.TurnPlayerID); playerID != g.TurnPlayerID; playerID = g.nextPlayerID(playerID) {
+			if !g.RoundFinishedConfirmedPlayerIDs[playerID] {
+				g.setTurn(playerID)
+				break
+			}
 		}
 	}
 
 	// Handle end of game due to score
-	for playerID := range g.Players {
-		if g.Players[playerID].Score >= g.RuleMaxPoints {
-			g.Players[playerID].Score = g.RuleMaxPoints
+	for playerID := 0; playerID < len(g.Players); playerID++ {
+		if g.Players[playerID].Score >= g.Rules.MaxPoints {
+			g.Players[playerID].Score = g.Rules.MaxPoints
+			if !g.IsGameEnded {
+				g.WinnerPlayerID = playerID
+				g.emit(&EventGameEnded{evt: evt{Type: EVENT_GAME_ENDED}, WinnerPlayerID: playerID})
+			}
 			g.IsGameEnded = true
-			g.WinnerPlayerID = playerID
 		}
 	}
+	g.updateStages()
 
 	possibleActions := g.CalculatePossibleActions()
 	if g.countActionsOfTurnPlayer() == 0 {
-		// If the current player has no actions left, it's the opponent's turn.
-		g.changeTurn()
+		// If the current player has no actions left, it's the turn of the next one who does.
+		nextPlayerID := g.nextPlayerID(g.TurnPlayerID)
+		for playerID := nextPlayerID; playerID != g.TurnPlayerID; playerID = g.nextPlayerID(playerID) {
+			if g.countActionsOfPlayer(possibleActions, playerID) > 0 {
+				nextPlayerID = playerID
+				break
+			}
+		}
+		g.setTurn(nextPlayerID)
 		possibleActions = g.CalculatePossibleActions()
 	}
 
@@ -279,33 +580,324 @@ func (g *GameState) RunAction(action Action) error {
 	return nil
 }
 
+// turnPasser is implemented by actions that decide who plays next when they yield the turn,
+// rather than yielding it to the turn opponent. Only needed for team games, where the next
+// player isn't necessarily the one who answers bets.
+type turnPasser interface {
+	nextTurnPlayerID(g GameState) int
+}
+
+// isEnvidoOrFlorInProgress returns whether an envido or flor sequence was started and is waiting for more actions.
+func (g GameState) isEnvidoOrFlorInProgress() bool {
+	isEnvidoInProgress := !g.EnvidoSequence.IsEmpty() && !g.IsEnvidoFinished && !g.EnvidoSequence.IsFinished()
+	isFlorInProgress := !g.FlorSequence.IsEmpty() && !g.FlorSequence.IsFinished()
+	return isEnvidoInProgress || isFlorInProgress
+}
+
+// changeTurn gives the turn to the rival of the player whose turn it is, who must answer them.
 func (g *GameState) changeTurn() {
-	g.TurnPlayerID, g.TurnOpponentPlayerID = g.TurnOpponentPlayerID, g.TurnPlayerID
+	answeredPlayerID := g.TurnPlayerID
+	g.setTurn(g.rivalPlayerID())
+	g.AnsweredPlayerID = answeredPlayerID
+}
+
+func (g *GameState) setTurn(playerID int) {
+	g.TurnPlayerID = playerID
+	g.TurnOpponentPlayerID = g.OpponentOf(playerID)
+	g.AnsweredPlayerID = -1
+}
+
+// rivalPlayerID returns the player the player whose turn it is is facing, i.e. the one they're
+// answering, or else their opponent. Bets are answered by, and points not fought for go to, the
+// rival's team.
+func (g GameState) rivalPlayerID() int {
+	if g.AnsweredPlayerID != -1 {
+		return g.AnsweredPlayerID
+	}
+	return g.TurnOpponentPlayerID
 }
 
 func (g GameState) countActionsOfTurnPlayer() int {
+	return g.countActionsOfPlayer(g.CalculatePossibleActions(), g.TurnPlayerID)
+}
+
+func (g GameState) countActionsOfPlayer(actions []Action, playerID int) int {
 	count := 0
-	for _, a := range g.CalculatePossibleActions() {
-		if a.GetPlayerID() == g.TurnPlayerID {
+	for _, a := range actions {
+		if a.GetPlayerID() == playerID {
 			count++
 		}
 	}
 	return count
 }
 
+// nextPlayerID returns the player seated after the given one, i.e. who plays after them.
+func (g GameState) nextPlayerID(playerID int) int {
+	return (playerID + 1 + len(g.Players)) % len(g.Players)
+}
+
+// OpponentOf returns the opponent who answers the given player's bets: the next player around
+// the table, who is always on the other team. In pica-pica rounds, it's the player seated across.
 func (g GameState) OpponentOf(playerID int) int {
-	for id := range g.Players {
-		if id != playerID {
-			return id
+	if g.RoundType == ROUND_TYPE_PICA_PICA {
+		return (playerID + len(g.Players)/2) % len(g.Players)
+	}
+	return g.nextPlayerID(playerID)
+}
+
+// roundPlayerIndex returns the index of the given player in RoundPlayerIDs, or -1 if they
+// don't play the current round.
+func (g GameState) roundPlayerIndex(playerID int) int {
+	for i, id := range g.RoundPlayerIDs {
+		if id == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// TeamOf returns the team of the given player: 0 for even player IDs, and 1 for odd ones. In
+// 2-player games, each player is a team of one.
+func (g GameState) TeamOf(playerID int) int {
+	return teamOf(playerID)
+}
+
+func teamOf(playerID int) int {
+	return playerID % 2
+}
+
+// addPoints adds points to the score of the given player's team, and logs them in the round's TeamPoints
+// and ScoresLog. The reason is one of the POINTS_REASON_* constants.
+//
+// The log entry's action is set by RunAction, once the action that awarded the points is over.
+func (g *GameState) addPoints(playerID int, points int, reason string) {
+	scoreBefore := g.Players[playerID].Score
+	for id, player := range g.Players {
+		if teamOf(id) == teamOf(playerID) {
+			player.Score += points
+		}
+	}
+	roundLog := g.RoundsLog[g.RoundNumber]
+	roundLog.TeamPoints[teamOf(playerID)] += points
+	roundLog.ScoresLog = append(roundLog.ScoresLog, ScoreLog{
+		Reason:      reason,
+		PlayerID:    playerID,
+		TeamID:      teamOf(playerID),
+		Points:      points,
+		ScoreBefore: scoreBefore,
+		ScoreAfter:  min(scoreBefore+points, g.Rules.MaxPoints),
+	})
+	g.emit(&EventPointsAwarded{evt: evt{Type: EVENT_POINTS_AWARDED}, TeamID: teamOf(playerID), Points: points, Reason: reason})
+}
+
+// updateStages sets every player's Stage according to their score.
+func (g *GameState) updateStages() {
+	for _, player := range g.Players {
+		player.Stage = g.Rules.StageOf(player.Score)
+	}
+}
+
+// teamEnvidoPlayerID returns the player with the best envido score in the given player's team,
+// i.e. who plays the envido for the team. On a tie, it's the one closest to mano.
+func (g GameState) teamEnvidoPlayerID(playerID int) int {
+	best := -1
+	for _, id := range g.RoundPlayerIDs {
+		if teamOf(id) != teamOf(playerID) {
+			continue
+		}
+		if best == -1 || g.Players[id].Hand.EnvidoScore() > g.Players[best].Hand.EnvidoScore() {
+			best = id
 		}
 	}
-	return -1 // Unreachable
+	return best
+}
+
+// teamEnvidoScore returns the best envido score in the given player's team.
+func (g GameState) teamEnvidoScore(playerID int) int {
+	return g.Players[g.teamEnvidoPlayerID(playerID)].Hand.EnvidoScore()
 }
 
 func (g GameState) Serialize() ([]byte, error) {
 	return json.Marshal(g)
 }
 
+// Clone returns a deep copy of the game state, which can be modified (e.g. by running actions)
+// without affecting the original. Use it to keep snapshots of a game, e.g. to undo actions.
+//
+// Options that can't be copied (WithRandSource and WithDealer) are shared with the original.
+func (g GameState) Clone() *GameState {
+	clone := g
+	clone.Players = make(map[int]*Player, len(g.Players))
+	for playerID, player := range g.Players {
+		clonedPlayer := *player
+		if player.Hand != nil {
+			clonedPlayer.Hand = player.Hand.clone()
+		}
+		clone.Players[playerID] = &clonedPlayer
+	}
+	clone.RoundPlayerIDs = _cloneSlice(g.RoundPlayerIDs)
+	clone.PossibleActions = _cloneSlice(g.PossibleActions)
+	clone.Events = _cloneSlice(g.Events)
+	if g.EnvidoSequence != nil {
+		clone.EnvidoSequence = g.EnvidoSequence.Clone()
+	}
+	if g.TrucoSequence != nil {
+		clone.TrucoSequence = g.TrucoSequence.Clone()
+	}
+	if g.FlorSequence != nil {
+		clone.FlorSequence = g.FlorSequence.Clone()
+	}
+	if g.CardRevealSequence != nil {
+		clone.CardRevealSequence = g.CardRevealSequence.Clone()
+	}
+	clone.RoundsLog = make([]*RoundLog, len(g.RoundsLog))
+	for i, roundLog := range g.RoundsLog {
+		clone.RoundsLog[i] = roundLog.clone()
+	}
+	if g.RoundFinishedConfirmedPlayerIDs != nil {
+		clone.RoundFinishedConfirmedPlayerIDs = make(map[int]bool, len(g.RoundFinishedConfirmedPlayerIDs))
+		for playerID, confirmed := range g.RoundFinishedConfirmedPlayerIDs {
+			clone.RoundFinishedConfirmedPlayerIDs[playerID] = confirmed
+		}
+	}
+	if g.deck != nil {
+		clone.deck = &deck{cards: _cloneSlice(g.deck.cards)}
+		clone.deck.dealHandFunc = clone.deck.defaultDealHand
+	}
+	return &clone
+}
+
+func (rl RoundLog) clone() *RoundLog {
+	clone := rl
+	if rl.HandsDealt != nil {
+		clone.HandsDealt = make(map[int]*Hand, len(rl.HandsDealt))
+		for playerID, hand := range rl.HandsDealt {
+			clone.HandsDealt[playerID] = hand.clone()
+		}
+	}
+	clone.PlayerIDs = _cloneSlice(rl.PlayerIDs)
+	if rl.Mazo != nil {
+		mazo := *rl.Mazo
+		clone.Mazo = &mazo
+	}
+	// Actions are immutable once logged, so they can be shared.
+	clone.ActionsLog = _cloneSlice(rl.ActionsLog)
+	clone.ScoresLog = _cloneSlice(rl.ScoresLog)
+	if rl.RevealedCards != nil {
+		clone.RevealedCards = make(map[int][]Card, len(rl.RevealedCards))
+		for playerID, cards := range rl.RevealedCards {
+			clone.RevealedCards[playerID] = _cloneSlice(cards)
+		}
+	}
+	return &clone
+}
+
+// gameStateJSON is the JSON representation of a GameState. It includes the deck, so that
+// a serialized GameState can be restored with DeserializeGameState.
+type gameStateJSON struct {
+	*gameStateAlias
+	Deck   []Card            `json:"deck"`
+	Events []json.RawMessage `json:"events,omitempty"`
+
+	// Game states serialized before Rules existed have these instead.
+	LegacyRuleMaxPoints     *int  `json:"ruleMaxPoints,omitempty"`
+	LegacyRuleIsFlorEnabled *bool `json:"ruleIsFlorEnabled,omitempty"`
+	LegacyRulePicaPicaFrom  int   `json:"rulePicaPicaFrom,omitempty"`
+	LegacyRulePicaPicaTo    int   `json:"rulePicaPicaTo,omitempty"`
+}
+
+// gameStateAlias has GameState's fields but not its methods, to avoid recursing into MarshalJSON.
+type gameStateAlias GameState
+
+func (g GameState) MarshalJSON() ([]byte, error) {
+	gj := gameStateJSON{gameStateAlias: (*gameStateAlias)(&g)}
+	if g.deck != nil {
+		gj.Deck = g.deck.cards
+	}
+	if len(g.Events) > 0 {
+		gj.Events = _serializeEvents(g.Events)
+	}
+	return json.Marshal(gj)
+}
+
+func (g *GameState) UnmarshalJSON(bs []byte) error {
+	gj := gameStateJSON{gameStateAlias: (*gameStateAlias)(g)}
+	if err := json.Unmarshal(bs, &gj); err != nil {
+		return err
+	}
+	g.deck = &deck{cards: gj.Deck}
+	g.deck.dealHandFunc = g.deck.defaultDealHand
+	g.Events = nil
+	for _, bs := range gj.Events {
+		event, err := DeserializeEvent(bs)
+		if err != nil {
+			return err
+		}
+		g.Events = append(g.Events, event)
+	}
+	if gj.LegacyRuleMaxPoints != nil {
+		g.Rules.MaxPoints = *gj.LegacyRuleMaxPoints
+	}
+	if gj.LegacyRuleIsFlorEnabled != nil {
+		g.Rules.IsFlorEnabled = *gj.LegacyRuleIsFlorEnabled
+	}
+	if gj.LegacyRulePicaPicaTo > 0 {
+		g.Rules.PicaPicaFrom, g.Rules.PicaPicaTo = gj.LegacyRulePicaPicaFrom, gj.LegacyRulePicaPicaTo
+	}
+	g.Rules = g.Rules.withDefaults()
+	g.updateStages()
+	return nil
+}
+
+// DeserializeGameState restores a GameState serialized with GameState.Serialize.
+//
+// The restored GameState can keep being played as if it was never serialized. PossibleActions
+// are recalculated rather than trusted, since they are derived from the rest of the state.
+func DeserializeGameState(bs []byte) (*GameState, error) {
+	var g GameState
+	if err := json.Unmarshal(bs, &g); err != nil {
+		return nil, err
+	}
+	var answered struct {
+		AnsweredPlayerID *int `json:"answeredPlayerID"`
+	}
+	if err := json.Unmarshal(bs, &answered); err != nil {
+		return nil, err
+	}
+	if g.Players == nil || g.RoundNumber < 1 || g.RoundNumber >= len(g.RoundsLog) {
+		return nil, errInvalidSerializedGameState
+	}
+	for _, player := range g.Players {
+		if player == nil || player.Hand == nil {
+			return nil, errInvalidSerializedGameState
+		}
+	}
+	if g.EnvidoSequence == nil || g.TrucoSequence == nil || g.FlorSequence == nil || g.CardRevealSequence == nil {
+		return nil, errInvalidSerializedGameState
+	}
+	if g.RoundFinishedConfirmedPlayerIDs == nil {
+		g.RoundFinishedConfirmedPlayerIDs = map[int]bool{}
+	}
+	// Older game states only have normal rounds, which are played by all players.
+	if g.RoundType == "" {
+		g.RoundType = ROUND_TYPE_NORMAL
+	}
+	if g.RoundPlayerIDs == nil {
+		for i := 0; i < len(g.Players); i++ {
+			g.RoundPlayerIDs = append(g.RoundPlayerIDs, (g.RoundTurnPlayerID+i)%len(g.Players))
+		}
+	}
+	// In older game states, TurnOpponentPlayerID is the rival of the player whose turn it is.
+	if answered.AnsweredPlayerID == nil {
+		g.AnsweredPlayerID = g.TurnOpponentPlayerID
+		g.TurnOpponentPlayerID = g.OpponentOf(g.TurnPlayerID)
+	}
+	g.PossibleActions = _serializeActions(g.CalculatePossibleActions())
+	return &g, nil
+}
+
+var errInvalidSerializedGameState = errors.New("invalid serialized game state")
+
 func (g *GameState) PrettyPrint() (string, error) {
 	var prettyJSON []byte
 	prettyJSON, err := json.MarshalIndent(g, "", "    ")
@@ -332,13 +924,19 @@ func (g *GameState) canAwardEnvidoPoints(revealedHand Hand) bool {
 	return true
 }
 
+// emitEnvidoResolved emits the envido's result, once its winner and points are logged.
+func (g *GameState) emitEnvidoResolved() {
+	roundLog := g.RoundsLog[g.RoundNumber]
+	g.emit(&EventEnvidoResolved{evt: evt{Type: EVENT_ENVIDO_RESOLVED}, WinnerPlayerID: roundLog.EnvidoWinnerPlayerID, Points: roundLog.EnvidoPoints, WasAccepted: g.EnvidoSequence.WasAccepted()})
+}
+
 func (g *GameState) tryAwardEnvidoPoints(playerID int) bool {
 	if !g.canAwardEnvidoPoints(Hand{Revealed: g.Players[playerID].Hand.Revealed}) {
 		return false
 	}
 	wonBy := g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID
 	score := g.RoundsLog[g.RoundNumber].EnvidoPoints
-	g.Players[wonBy].Score += score
+	g.addPoints(wonBy, score, POINTS_REASON_ENVIDO)
 	g.EnvidoSequence.EnvidoPointsAwarded = true
 	return true
 }
@@ -368,14 +966,18 @@ type Action interface {
 	fmt.Stringer
 }
 
+// Errors returned by GameState.RunAction. Use errors.Is to check for them, since they may be wrapped.
 var (
-	errActionNotPossible = errors.New("action not possible")
-	errEnvidoFinished    = errors.New("envido finished")
-	errGameIsEnded       = errors.New("game is ended")
-	errNotYourTurn       = errors.New("not your turn")
+	ErrActionNotPossible = errors.New("action not possible")
+	ErrEnvidoFinished    = errors.New("envido finished")
+	ErrGameIsEnded       = errors.New("game is ended")
+	ErrNotYourTurn       = errors.New("not your turn")
 )
 
 func (g GameState) CalculatePossibleActions() []Action {
+	if g.IsGameEnded {
+		return []Action{}
+	}
 	allActions := []Action{}
 	allActions = append(allActions, NewActionsRevealCards(g.TurnPlayerID, g)...)
 	allActions = append(allActions,
@@ -392,10 +994,18 @@ func (g GameState) CalculatePossibleActions() []Action {
 		NewActionSayQuieroValeCuatro(g.TurnPlayerID),
 		NewActionSaySonBuenas(g.TurnPlayerID),
 		NewActionSaySonMejores(g.TurnPlayerID),
-		NewActionConfirmRoundFinished(g.TurnPlayerID),
-		NewActionConfirmRoundFinished(g.TurnOpponentPlayerID),
-		NewActionRevealEnvidoScore(g.TurnPlayerID),
-		NewActionRevealEnvidoScore(g.TurnOpponentPlayerID),
+	)
+	// Once the round is finished, all players have to confirm it, and the envido winner may
+	// have to reveal their score, regardless of whose turn it is.
+	for i := 0; i < len(g.Players); i++ {
+		playerID := (g.TurnPlayerID + i) % len(g.Players)
+		allActions = append(allActions, NewActionConfirmRoundFinished(playerID))
+	}
+	for i := 0; i < len(g.Players); i++ {
+		playerID := (g.TurnPlayerID + i) % len(g.Players)
+		allActions = append(allActions, NewActionRevealEnvidoScore(playerID))
+	}
+	allActions = append(allActions,
 		NewActionSayFlor(g.TurnPlayerID),
 		NewActionSayContraflor(g.TurnPlayerID),
 		NewActionSayContraflorAlResto(g.TurnPlayerID),
@@ -405,7 +1015,7 @@ func (g GameState) CalculatePossibleActions() []Action {
 		NewActionSayFlorSonBuenas(g.TurnPlayerID),
 		NewActionSayFlorSonMejores(g.TurnPlayerID),
 		NewActionRevealFlorScore(g.TurnPlayerID),
-		NewActionRevealFlorScore(g.TurnOpponentPlayerID),
+		NewActionRevealFlorScore(g.rivalPlayerID()),
 		NewActionSayMeVoyAlMazo(g.TurnPlayerID),
 	)
 
@@ -477,6 +1087,8 @@ func DeserializeAction(bs []byte) (Action, error) {
 		action = &ActionSayMeVoyAlMazo{}
 	case CONFIRM_ROUND_FINISHED:
 		action = &ActionConfirmRoundFinished{}
+	case FORFEIT:
+		action = &ActionForfeit{}
 	case REVEAL_ENVIDO_SCORE:
 		action = &ActionRevealEnvidoScore{}
 	case SAY_FLOR:
@@ -517,6 +1129,14 @@ func _serializeActions(as []Action) []json.RawMessage {
 	return _as
 }
 
+// _cloneSlice copies a slice, keeping it nil if it was nil, so that the copy serializes the same.
+func _cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
 func _deserializeCurrentRoundLastAction(g GameState) Action {
 	lastAction := g.RoundsLog[g.RoundNumber].ActionsLog[len(g.RoundsLog[g.RoundNumber].ActionsLog)-1].Action
 	a, _ := DeserializeAction(lastAction)
@@ -544,49 +1164,65 @@ func _deserializeCurrentRoundActionsByPlayerID(playerID int, g GameState) []Acti
 	return filteredActions
 }
 
+// ToClientGameState returns what the given player can see of the game (see viewOf).
 func (g *GameState) ToClientGameState(youPlayerID int) ClientGameState {
-	themPlayerID := g.OpponentOf(youPlayerID)
-
-	// GameState may have possible game actions that this player can't take.
-	filteredPossibleActions := []Action{}
-	for _, a := range g.CalculatePossibleActions() {
-		if a.GetPlayerID() == youPlayerID {
-			filteredPossibleActions = append(filteredPossibleActions, a)
-		}
-	}
+	// Only read from the player's view, so that the other players' hidden cards can't leak.
+	view := g.viewOf(youPlayerID)
+	themPlayerID := view.OpponentOf(youPlayerID)
 
 	cgs := ClientGameState{
-		RoundTurnPlayerID:           g.RoundTurnPlayerID,
-		RoundNumber:                 g.RoundNumber,
-		TurnPlayerID:                g.TurnPlayerID,
+		RoundTurnPlayerID:           view.RoundTurnPlayerID,
+		RoundNumber:                 view.RoundNumber,
+		RoundType:                   view.RoundType,
+		RoundPlayerIDs:              view.RoundPlayerIDs,
+		TurnPlayerID:                view.TurnPlayerID,
 		YouPlayerID:                 youPlayerID,
 		ThemPlayerID:                themPlayerID,
-		YourScore:                   g.Players[youPlayerID].Score,
-		TheirScore:                  g.Players[themPlayerID].Score,
-		YourRevealedCards:           g.Players[youPlayerID].Hand.Revealed,
-		TheirRevealedCards:          g.Players[themPlayerID].Hand.Revealed,
-		YourUnrevealedCards:         g.Players[youPlayerID].Hand.Unrevealed,
-		PossibleActions:             _serializeActions(filteredPossibleActions),
-		IsGameEnded:                 g.IsGameEnded,
-		IsRoundFinished:             g.IsRoundFinished,
-		WinnerPlayerID:              g.WinnerPlayerID,
-		EnvidoWinnerPlayerID:        g.RoundsLog[g.RoundNumber].EnvidoWinnerPlayerID,
-		WasEnvidoAccepted:           g.EnvidoSequence.WasAccepted(),
-		EnvidoPoints:                g.RoundsLog[g.RoundNumber].EnvidoPoints,
-		TrucoWinnerPlayerID:         g.RoundsLog[g.RoundNumber].TrucoWinnerPlayerID,
-		TrucoPoints:                 g.RoundsLog[g.RoundNumber].TrucoPoints,
-		WasTrucoAccepted:            g.TrucoSequence.WasAccepted(),
-		FlorWinnerPlayerID:          g.RoundsLog[g.RoundNumber].FlorWinnerPlayerID,
-		WasFlorAccepted:             g.FlorSequence.WasAccepted(),
-		FlorPoints:                  g.RoundsLog[g.RoundNumber].FlorPoints,
-		YourDisplayUnrevealedCards:  g.Players[youPlayerID].Hand.prepareDisplayUnrevealedCards(true),
-		TheirDisplayUnrevealedCards: g.Players[themPlayerID].Hand.prepareDisplayUnrevealedCards(false),
-		RuleMaxPoints:               g.RuleMaxPoints,
-		RuleIsFlorEnabled:           g.RuleIsFlorEnabled,
-	}
-
-	if len(g.RoundsLog[g.RoundNumber].ActionsLog) > 0 {
-		actionsLog := g.RoundsLog[g.RoundNumber].ActionsLog
+		YourScore:                   view.Players[youPlayerID].Score,
+		TheirScore:                  view.Players[themPlayerID].Score,
+		YourStage:                   view.Players[youPlayerID].Stage,
+		TheirStage:                  view.Players[themPlayerID].Stage,
+		YourRevealedCards:           view.Players[youPlayerID].Hand.Revealed,
+		TheirRevealedCards:          view.Players[themPlayerID].Hand.Revealed,
+		YourUnrevealedCards:         view.Players[youPlayerID].Hand.Unrevealed,
+		PossibleActions:             view.PossibleActions,
+		Events:                      _serializeEvents(view.Events),
+		ScoresLog:                   view.RoundsLog[view.RoundNumber].ScoresLog,
+		History:                     view.clientHistory(youPlayerID),
+		IsGameEnded:                 view.IsGameEnded,
+		IsRoundFinished:             view.IsRoundFinished,
+		WinnerPlayerID:              view.WinnerPlayerID,
+		EnvidoWinnerPlayerID:        view.RoundsLog[view.RoundNumber].EnvidoWinnerPlayerID,
+		WasEnvidoAccepted:           view.EnvidoSequence.WasAccepted(),
+		EnvidoPoints:                view.RoundsLog[view.RoundNumber].EnvidoPoints,
+		TrucoWinnerPlayerID:         view.RoundsLog[view.RoundNumber].TrucoWinnerPlayerID,
+		TrucoPoints:                 view.RoundsLog[view.RoundNumber].TrucoPoints,
+		WasTrucoAccepted:            view.TrucoSequence.WasAccepted(),
+		FlorWinnerPlayerID:          view.RoundsLog[view.RoundNumber].FlorWinnerPlayerID,
+		WasFlorAccepted:             view.FlorSequence.WasAccepted(),
+		FlorPoints:                  view.RoundsLog[view.RoundNumber].FlorPoints,
+		YourDisplayUnrevealedCards:  view.Players[youPlayerID].Hand.prepareDisplayUnrevealedCards(true),
+		TheirDisplayUnrevealedCards: view.Players[themPlayerID].Hand.prepareDisplayUnrevealedCards(false),
+		RuleMaxPoints:               view.Rules.MaxPoints,
+		RuleIsFlorEnabled:           view.Rules.IsFlorEnabled,
+		Rules:                       view.Rules,
+		YourTeamID:                  view.TeamOf(youPlayerID),
+		Players:                     []ClientPlayer{},
+	}
+
+	for playerID := 0; playerID < len(view.Players); playerID++ {
+		cgs.Players = append(cgs.Players, ClientPlayer{
+			PlayerID:               playerID,
+			TeamID:                 view.TeamOf(playerID),
+			Score:                  view.Players[playerID].Score,
+			Stage:                  view.Players[playerID].Stage,
+			RevealedCards:          view.Players[playerID].Hand.Revealed,
+			DisplayUnrevealedCards: view.Players[playerID].Hand.prepareDisplayUnrevealedCards(playerID == youPlayerID),
+		})
+	}
+
+	if len(view.RoundsLog[view.RoundNumber].ActionsLog) > 0 {
+		actionsLog := view.RoundsLog[view.RoundNumber].ActionsLog
 		cgs.LastActionLog = &actionsLog[len(actionsLog)-1]
 	}
 
@@ -604,11 +1240,17 @@ type ClientGameState struct {
 	// RoundNumber is the number of the current round, starting from 1.
 	RoundNumber int `json:"roundNumber"`
 
+	// RoundType and RoundPlayerIDs are like in GameState. In a pica-pica duel, only the players in
+	// RoundPlayerIDs play, and ThemPlayerID is your opponent in your duel.
+	RoundType      string `json:"roundType"`
+	RoundPlayerIDs []int  `json:"roundPlayerIDs"`
+
 	// TurnPlayerID is the player ID of the player whose turn it is to play an action.
 	// This is different from RoundTurnPlayerID, which is the player who starts the round.
 	// They are the same at the beginning of the round.
 	TurnPlayerID int `json:"turnPlayerID"`
 
+	// ThemPlayerID is the opponent who answers your bets. In team games, Players has everybody else.
 	YouPlayerID         int    `json:"you"`
 	ThemPlayerID        int    `json:"them"`
 	YourScore           int    `json:"yourScore"`
@@ -617,6 +1259,10 @@ type ClientGameState struct {
 	TheirRevealedCards  []Card `json:"theirRevealedCards"`
 	YourUnrevealedCards []Card `json:"yourUnrevealedCards"`
 
+	// YourStage and TheirStage are STAGE_MALAS or STAGE_BUENAS, as in Player.Stage.
+	YourStage  string `json:"yourStage"`
+	TheirStage string `json:"theirStage"`
+
 	// YourDisplayUnrevealedCards is like YourUnrevealedCards, but it always has 3 cards
 	// and it adds two properties: `IsBackwards` and `IsHole`.
 	//
@@ -661,13 +1307,68 @@ type ClientGameState struct {
 	TrucoPoints          int  `json:"trucoPoints"`
 	WasTrucoAccepted     bool `json:"wasTrucoAccepted"`
 
+	// Events are the serialized events of the last action that was run (see GameState.Events). Use
+	// DeserializeEvent to read them. They're empty if the state wasn't sent because of an action,
+	// e.g. when a client asks for it.
+	Events []json.RawMessage `json:"events"`
+
+	// ScoresLog is the ordered list of score changes of the current round (see RoundLog.ScoresLog).
+	// Clients typically want to use it to explain the points of each team at the end of a round.
+	ScoresLog []ScoreLog `json:"scoresLog"`
+
+	// History is the log of every round so far, in order, the last one being the current round
+	// (i.e. History[i] is round number i+1). It only has what you saw, so it's safe to show it,
+	// e.g. as a game log for a client that reconnects.
+	History []ClientRoundLog `json:"history"`
+
 	// LastActionLog is the log of the last action that was run in the current round. If the round has
 	// just started, this will be nil. Clients typically want to user this to show the current player
 	// what the opponent just did.
 	LastActionLog *ActionLog `json:"lastActionLog"`
 
-	RuleMaxPoints     int  `json:"ruleMaxPoints"`
-	RuleIsFlorEnabled bool `json:"ruleIsFlorEnabled"`
+	// RuleMaxPoints and RuleIsFlorEnabled are the same as in Rules, which has all the house rules.
+	RuleMaxPoints     int   `json:"ruleMaxPoints"`
+	RuleIsFlorEnabled bool  `json:"ruleIsFlorEnabled"`
+	Rules             Rules `json:"rules"`
+
+	// YourTeamID is your team, as in GameState.TeamOf. YourScore and TheirScore are the teams' scores.
+	YourTeamID int `json:"yourTeamID"`
+
+	// Players has what everybody can see of each player, in player ID order. This is needed to
+	// render team games, where "you" and "them" aren't all the players.
+	Players []ClientPlayer `json:"players"`
+
+	// Clock is the time players have left to act. It's set by the server on games with a clock;
+	// otherwise, it's nil.
+	Clock *ClientClock `json:"clock,omitempty"`
+}
+
+// ClientPlayer is what everybody can see of a player.
+type ClientPlayer struct {
+	PlayerID      int    `json:"playerID"`
+	TeamID        int    `json:"teamID"`
+	Score         int    `json:"score"`
+	Stage         string `json:"stage"`
+	RevealedCards []Card `json:"revealedCards"`
+
+	// DisplayUnrevealedCards is like ClientGameState.TheirDisplayUnrevealedCards (or YourDisplayUnrevealedCards
+	// for you), so unrevealed cards of other players are facing backwards.
+	DisplayUnrevealedCards []DisplayCard `json:"displayUnrevealedCards"`
+}
+
+// ClientClock is the time players have left to act, in milliseconds. A value of -1 means
+// there is no limit.
+type ClientClock struct {
+	// RunningForPlayerID is the player whose clock is running, or -1 if no clock is running
+	// (e.g. while waiting for players to join, or after the game ended).
+	RunningForPlayerID int `json:"runningForPlayerID"`
+
+	// YourRemainingMs and TheirRemainingMs are the total times left for the rest of the game.
+	YourRemainingMs  int64 `json:"yourRemainingMs"`
+	TheirRemainingMs int64 `json:"theirRemainingMs"`
+
+	// MoveRemainingMs is the time left for the current move, when the clock was sent.
+	MoveRemainingMs int64 `json:"moveRemainingMs"`
 }
 
 type Bot interface {
@@ -52,6 +52,11 @@ type TrucoSequence struct {
 	// sequence. This is used to determine who can raise the stakes in the truco sequence.
 	QuieroOwnerPlayerID int `json:"quiero_owner_player_id"`
 
+	// AnsweringPlayerID is the player who must answer the last truco bet. It's needed because
+	// an envido (or flor) may interrupt the truco before it's answered, and then the truco
+	// must be resumed by this player once the envido is over.
+	AnsweringPlayerID int `json:"answering_player_id"`
+
 	Sequence []string `json:"sequence"`
 }
 
@@ -91,6 +96,18 @@ func (ts *TrucoSequence) IsFinished() bool {
 	return last == SAY_TRUCO_QUIERO || last == SAY_TRUCO_NO_QUIERO
 }
 
+// IsPending returns whether the last truco bet is waiting for an answer.
+func (ts TrucoSequence) IsPending() bool {
+	return !ts.IsEmpty() && !ts.IsFinished()
+}
+
+// CanBeInterruptedByEnvido returns whether envido can be said in the middle of the truco sequence.
+// This is the "el envido está primero" rule: the first truco may be interrupted by an envido until
+// it's answered, but not afterwards.
+func (ts TrucoSequence) CanBeInterruptedByEnvido() bool {
+	return len(ts.Sequence) == 1
+}
+
 func (ts TrucoSequence) Cost() int {
 	return validTrucoSequenceCosts[ts.String()]
 }
@@ -113,9 +130,10 @@ func (ts TrucoSequence) IsSubsequenceStart() bool {
 
 func (es TrucoSequence) Clone() *TrucoSequence {
 	return &TrucoSequence{
-		Sequence:            append([]string{}, es.Sequence...),
+		Sequence:            _cloneSlice(es.Sequence),
 		StartingPlayerID:    es.StartingPlayerID,
 		QuieroOwnerPlayerID: es.QuieroOwnerPlayerID,
+		AnsweringPlayerID:   es.AnsweringPlayerID,
 	}
 }
 
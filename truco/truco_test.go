@@ -1,6 +1,8 @@
 package truco
 
 import (
+	"errors"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -49,3 +51,165 @@ func TestAfterRealEnvidoOptions(t *testing.T) {
 		require.Equal(t, string(expectedAction), string(gameState.PossibleActions[i]))
 	}
 }
+
+func TestDeserializeGameState(t *testing.T) {
+	gameState := New(WithFlorEnabled(true))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[0].Hand.Unrevealed[0], 0)))
+
+	bs, err := gameState.Serialize()
+	require.NoError(t, err)
+	restored, err := DeserializeGameState(bs)
+	require.NoError(t, err)
+
+	// Private state is restored too.
+	require.Equal(t, gameState.deck.cards, restored.deck.cards)
+	require.Equal(t, gameState.CardRevealSequence, restored.CardRevealSequence)
+	require.Equal(t, gameState.Players[0].Hand.displayUnrevealedCards, restored.Players[0].Hand.displayUnrevealedCards)
+	require.Equal(t, gameState.ToClientGameState(1), restored.ToClientGameState(1))
+
+	restoredBs, err := restored.Serialize()
+	require.NoError(t, err)
+	require.JSONEq(t, string(bs), string(restoredBs))
+
+	_, err = DeserializeGameState([]byte(`{}`))
+	require.Error(t, err)
+	_, err = DeserializeGameState([]byte(`not json`))
+	require.Error(t, err)
+}
+
+// TestSerializationRoundTripWhilePlaying plays random games, and at every step it reloads the
+// game state from its serialization, and checks that the reloaded game state behaves exactly
+// like the original one.
+func TestSerializationRoundTripWhilePlaying(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var (
+			r         = rand.New(rand.NewSource(int64(i)))
+			gameState = New(WithMaxPoints(15), WithFlorEnabled(i%2 == 0), WithSeed(int64(i)))
+		)
+		for step := 0; !gameState.IsGameEnded; step++ {
+			bs, err := gameState.Serialize()
+			require.NoError(t, err)
+			restored, err := DeserializeGameState(bs)
+			require.NoError(t, err)
+
+			restoredBs, err := restored.Serialize()
+			require.NoError(t, err)
+			require.Equal(t, string(bs), string(restoredBs), "game %v step %v", i, step)
+			require.Equal(t, gameState.CalculatePossibleActions(), restored.CalculatePossibleActions(), "game %v step %v", i, step)
+			for playerID := range gameState.Players {
+				require.Equal(t, gameState.ToClientGameState(playerID), restored.ToClientGameState(playerID), "game %v step %v", i, step)
+			}
+
+			actions := gameState.CalculatePossibleActions()
+			require.NotEmpty(t, actions, "game %v step %v", i, step)
+			action := actions[r.Intn(len(actions))]
+
+			err = gameState.RunAction(action)
+			require.Equal(t, err, restored.RunAction(action), "game %v step %v", i, step)
+			if errors.Is(err, ErrNotYourTurn) {
+				// Possible actions include some of the opponent's, which can't always be run yet.
+				continue
+			}
+			require.NoError(t, err)
+
+			bs, err = gameState.Serialize()
+			require.NoError(t, err)
+			restoredBs, err = restored.Serialize()
+			require.NoError(t, err)
+			require.Equal(t, string(bs), string(restoredBs), "game %v step %v after %v", i, step, action)
+
+			// Keep playing on the restored game state, so that restored states are restored again.
+			gameState = restored
+		}
+	}
+}
+
+// TestCloneIsIndependent plays random games, and at every step it checks that running an action
+// doesn't affect a clone taken before it, and that the clone behaves exactly like the original.
+func TestCloneIsIndependent(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var (
+			r         = rand.New(rand.NewSource(int64(i)))
+			gameState = New(WithMaxPoints(15), WithFlorEnabled(i%2 == 0), WithSeed(int64(i)))
+		)
+		for step := 0; !gameState.IsGameEnded; step++ {
+			clone := gameState.Clone()
+			before := serializeForTest(t, gameState)
+			require.Equal(t, before, serializeForTest(t, clone), "game %v step %v", i, step)
+
+			actions := gameState.CalculatePossibleActions()
+			action := actions[r.Intn(len(actions))]
+			err := gameState.RunAction(action)
+			if errors.Is(err, ErrNotYourTurn) {
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, before, serializeForTest(t, clone), "game %v step %v after %v", i, step, action)
+
+			require.NoError(t, clone.RunAction(action))
+			require.Equal(t, serializeForTest(t, gameState), serializeForTest(t, clone), "game %v step %v after %v", i, step, action)
+		}
+	}
+}
+
+func TestScoresLog(t *testing.T) {
+	hands := []Hand{
+		{Unrevealed: []Card{{Number: 1, Suit: COPA}, {Number: 2, Suit: ORO}, {Number: 3, Suit: ORO}}},   // 25
+		{Unrevealed: []Card{{Number: 4, Suit: COPA}, {Number: 5, Suit: ORO}, {Number: 6, Suit: BASTO}}}, // 5
+	}
+	gameState := New(withDeck(newTestDeck(hands)))
+	actions := []Action{
+		NewActionSayEnvido(0),
+		NewActionSayEnvidoQuiero(1),
+		NewActionSayEnvidoScore(0),
+		NewActionSaySonBuenas(1),
+		NewActionRevealCard(Card{Number: 2, Suit: ORO}, 0),
+		NewActionRevealCard(Card{Number: 4, Suit: COPA}, 1),
+		NewActionRevealCard(Card{Number: 3, Suit: ORO}, 0),
+		NewActionSayTruco(1),
+		NewActionSayTrucoNoQuiero(0),
+	}
+	for i, action := range actions {
+		action.Enrich(*gameState)
+		require.NoError(t, gameState.RunAction(action), "at action %v", i)
+	}
+
+	// The envido points are awarded when player 0 reveals their envido, and the truco points when they
+	// don't want player 1's truco.
+	require.Equal(t, []ScoreLog{
+		{Reason: POINTS_REASON_ENVIDO, PlayerID: 0, TeamID: 0, Points: 2, ScoreBefore: 0, ScoreAfter: 2, ActionPlayerID: 0, Action: SerializeAction(actions[6])},
+		{Reason: POINTS_REASON_TRUCO, PlayerID: 1, TeamID: 1, Points: 1, ScoreBefore: 0, ScoreAfter: 1, ActionPlayerID: 0, Action: SerializeAction(actions[8])},
+	}, gameState.RoundsLog[1].ScoresLog)
+	require.Equal(t, gameState.RoundsLog[1].ScoresLog, gameState.ToClientGameState(1).ScoresLog)
+}
+
+// TestScoresLogExplainsScores plays random games, and checks that the scores logs of all rounds
+// add up to the round's TeamPoints and to the final scores.
+func TestScoresLogExplainsScores(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var (
+			r         = rand.New(rand.NewSource(int64(i)))
+			gameState = New(WithMaxPoints(15), WithFlorEnabled(i%2 == 0), WithSeed(int64(i)))
+		)
+		for !gameState.IsGameEnded {
+			actions := gameState.CalculatePossibleActions()
+			_ = gameState.RunAction(actions[r.Intn(len(actions))])
+		}
+
+		scores := [2]int{}
+		for roundNumber, roundLog := range gameState.RoundsLog[1:] {
+			teamPoints := [2]int{}
+			for _, scoreLog := range roundLog.ScoresLog {
+				require.Equal(t, scores[scoreLog.TeamID], scoreLog.ScoreBefore, "game %v round %v", i, roundNumber+1)
+				require.Equal(t, min(scoreLog.ScoreBefore+scoreLog.Points, 15), scoreLog.ScoreAfter, "game %v round %v", i, roundNumber+1)
+				_, err := DeserializeAction(scoreLog.Action)
+				require.NoError(t, err, "game %v round %v", i, roundNumber+1)
+				scores[scoreLog.TeamID] = scoreLog.ScoreAfter
+				teamPoints[scoreLog.TeamID] += scoreLog.Points
+			}
+			require.Equal(t, roundLog.TeamPoints, teamPoints, "game %v round %v", i, roundNumber+1)
+		}
+		require.Equal(t, gameState.Players[0].Score, scores[0], "game %v", i)
+		require.Equal(t, gameState.Players[1].Score, scores[1], "game %v", i)
+	}
+}
@@ -0,0 +1,59 @@
+package truco
+
+// This file has the visibility policy of the game, i.e. what each player can see of it.
+//
+// A player can see:
+//   - everything that was said or done, i.e. the actions of every round, the scores, the results
+//     and the events,
+//   - the cards that every player revealed, either in a faceoff or to show their envido or flor,
+//   - their own hand, and the hands they were dealt in previous rounds,
+//   - their own possible actions.
+//
+// A player can't see the deck nor the seed that shuffles it, the unrevealed cards of the other
+// players (including their teammates), the hands that were dealt to them, nor their possible
+// actions.
+//
+// ToClientGameState only reads from the player's view of the game (see viewOf), which has the
+// hidden information removed, so that adding fields to ClientGameState can't leak it by accident.
+
+// viewOf returns a copy of the game state that only has what the given player can see.
+//
+// The copy is only meant to be read (e.g. its possible actions can't be calculated, since they may
+// depend on hidden cards), so its PossibleActions are the player's, calculated on the full game state.
+func (g GameState) viewOf(playerID int) *GameState {
+	possibleActions := []Action{}
+	for _, a := range g.CalculatePossibleActions() {
+		if a.GetPlayerID() == playerID {
+			possibleActions = append(possibleActions, a)
+		}
+	}
+
+	view := g.Clone()
+	view.PossibleActions = _serializeActions(possibleActions)
+	view.deck = nil
+	view.Seed = 0
+	view.randSource = nil
+	view.dealer = nil
+	for id, player := range view.Players {
+		if id != playerID && player.Hand != nil {
+			player.Hand = player.Hand.hidden()
+		}
+	}
+	for _, roundLog := range view.RoundsLog {
+		for id := range roundLog.HandsDealt {
+			if id != playerID {
+				delete(roundLog.HandsDealt, id)
+			}
+		}
+	}
+	return view
+}
+
+// hidden returns the hand as other players see it: only the revealed cards, and the display
+// cards facing backwards, except for the holes of the revealed ones.
+func (h *Hand) hidden() *Hand {
+	return &Hand{
+		Revealed:               _cloneSlice(h.Revealed),
+		displayUnrevealedCards: h.prepareDisplayUnrevealedCards(false),
+	}
+}
@@ -0,0 +1,133 @@
+package truco
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewOf(t *testing.T) {
+	gameState := New(WithPlayerCount(4), WithSeed(42))
+	require.NoError(t, gameState.RunAction(NewActionRevealCard(gameState.Players[0].Hand.Unrevealed[0], 0)))
+
+	view := gameState.viewOf(1)
+	require.Equal(t, gameState.Players[1].Hand.Unrevealed, view.Players[1].Hand.Unrevealed)
+	for _, playerID := range []int{0, 2, 3} {
+		require.Empty(t, view.Players[playerID].Hand.Unrevealed, "player %v", playerID)
+		require.Equal(t, gameState.Players[playerID].Hand.Revealed, view.Players[playerID].Hand.Revealed, "player %v", playerID)
+		require.Len(t, view.Players[playerID].Hand.prepareDisplayUnrevealedCards(false), 3, "player %v", playerID)
+	}
+	require.Len(t, view.RoundsLog[1].HandsDealt, 1)
+	require.NotNil(t, view.RoundsLog[1].HandsDealt[1])
+	require.Nil(t, view.deck)
+	require.Zero(t, view.Seed)
+	for _, bs := range view.PossibleActions {
+		action, err := DeserializeAction(bs)
+		require.NoError(t, err)
+		require.Equal(t, 1, action.GetPlayerID())
+	}
+
+	// The game state itself is untouched.
+	require.Len(t, gameState.Players[2].Hand.Unrevealed, 3)
+	require.Len(t, gameState.RoundsLog[1].HandsDealt, 4)
+	require.Equal(t, int64(42), gameState.Seed)
+}
+
+// TestClientGameStateNeverLeaksHiddenCards plays random games of every kind, and at every step it
+// checks that no player's serialized ClientGameState has a card that another player hasn't revealed.
+//
+// Cards are dealt again on every round, so a card that is hidden in a round may have been seen in
+// another one. Thus, the current round and each round in the history are checked separately.
+func TestClientGameStateNeverLeaksHiddenCards(t *testing.T) {
+	configs := []struct {
+		name string
+		opts []func(*GameState)
+	}{
+		{name: "two players", opts: []func(*GameState){WithMaxPoints(15)}},
+		{name: "two players with flor", opts: []func(*GameState){WithMaxPoints(15), WithFlorEnabled(true)}},
+		{name: "four players", opts: []func(*GameState){WithMaxPoints(15), WithPlayerCount(4)}},
+		{name: "six players with pica-pica", opts: []func(*GameState){WithPlayerCount(6), WithPicaPica(0, 30)}},
+		{name: "envido in the second faceoff", opts: []func(*GameState){WithMaxPoints(15), WithRules(Rules{IsFlorEnabled: true, IsEnvidoAllowedInSecondFaceoff: true, Mazo: MAZO_ENVIDO_CLAIMABLE})}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				var (
+					r         = rand.New(rand.NewSource(int64(i)))
+					gameState = New(append(config.opts, WithSeed(int64(i)))...)
+				)
+				for step := 0; !gameState.IsGameEnded; step++ {
+					for playerID := range gameState.Players {
+						requireNoHiddenCards(t, gameState, playerID, fmt.Sprintf("game %v step %v player %v", i, step, playerID))
+					}
+					actions := gameState.CalculatePossibleActions()
+					err := gameState.RunAction(actions[r.Intn(len(actions))])
+					if errors.Is(err, ErrNotYourTurn) {
+						continue
+					}
+					require.NoError(t, err, "game %v step %v", i, step)
+				}
+			}
+		})
+	}
+}
+
+func requireNoHiddenCards(t *testing.T, gameState *GameState, playerID int, msg string) {
+	clientGameState := gameState.ToClientGameState(playerID)
+	history := clientGameState.History
+	clientGameState.History = nil
+
+	// In the current round, the other players' unrevealed cards are hidden.
+	hidden := []Card{}
+	for otherPlayerID, player := range gameState.Players {
+		if otherPlayerID != playerID {
+			hidden = append(hidden, player.Hand.Unrevealed...)
+		}
+	}
+	requireNoCards(t, clientGameState, hidden, msg)
+
+	// In previous rounds, the cards dealt to the other players that they didn't reveal are hidden.
+	for _, clientRoundLog := range history {
+		if clientRoundLog.RoundNumber == gameState.RoundNumber {
+			requireNoCards(t, clientRoundLog, hidden, msg)
+			continue
+		}
+		roundLog := gameState.RoundsLog[clientRoundLog.RoundNumber]
+		revealed := map[Card]bool{}
+		for _, cards := range roundLog.RevealedCards {
+			for _, card := range cards {
+				revealed[card] = true
+			}
+		}
+		roundHidden := []Card{}
+		for otherPlayerID, hand := range roundLog.HandsDealt {
+			if otherPlayerID == playerID {
+				continue
+			}
+			for _, card := range append(append([]Card{}, hand.Unrevealed...), hand.Revealed...) {
+				if !revealed[card] {
+					roundHidden = append(roundHidden, card)
+				}
+			}
+		}
+		requireNoCards(t, clientRoundLog, roundHidden, fmt.Sprintf("%v round %v", msg, clientRoundLog.RoundNumber))
+	}
+}
+
+// requireNoCards checks that none of the cards is in the JSON serialization of v, either as
+// a Card or as a DisplayCard.
+func requireNoCards(t *testing.T, v any, cards []Card, msg string) {
+	bs, err := json.Marshal(v)
+	require.NoError(t, err, msg)
+	for _, card := range cards {
+		cardBs, _ := json.Marshal(card)
+		displayCardPrefix := strings.TrimSuffix(string(cardBs), "}") + ","
+		require.NotContains(t, string(bs), string(cardBs), msg)
+		require.NotContains(t, string(bs), displayCardPrefix, msg)
+	}
+}